@@ -0,0 +1,74 @@
+package gowhoson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tai-ga/gowhoson/pkg/whoson"
+	"github.com/urfave/cli/v3"
+)
+
+const historyTimeLayout = "2006-01-02 15:04:05"
+
+// parseHistoryTime parse local time or RFC3339 time, def is returned for empty string.
+func parseHistoryTime(s string, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if t, err := time.ParseInLocation(historyTimeLayout, s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("\"%s\" time parse error", s)
+	}
+	return t, nil
+}
+
+func cmdHistory(ctx context.Context, c *cli.Command) error {
+	config := c.Root().Metadata["config"].(*whoson.ServerCtlConfig)
+
+	if c.String("server") != "" {
+		config.Server = c.String("server")
+	}
+	if c.IsSet("json") {
+		config.JSON = c.Bool("json")
+	}
+
+	sc := whoson.NewServerCtl(config.Server)
+	sc.SetWriter(c.Root().Writer)
+	err := historyRequest(c, sc, time.Now())
+	if err != nil {
+		displayError(c.Root().ErrWriter, err)
+		return err
+	}
+
+	if config.JSON {
+		return sc.WriteJSON()
+	}
+	return sc.WriteHistoryTable()
+}
+
+func historyRequest(c *cli.Command, sc *whoson.ServerCtl, now time.Time) error {
+	switch {
+	case c.String("ip") != "" && c.String("user") == "":
+		t, err := parseHistoryTime(c.String("time"), now)
+		if err != nil {
+			return err
+		}
+		return sc.HistoryByIP(c.String("ip"), t)
+	case c.String("user") != "" && c.String("ip") == "":
+		from, err := parseHistoryTime(c.String("from"), now.Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+		to, err := parseHistoryTime(c.String("to"), now)
+		if err != nil {
+			return err
+		}
+		return sc.HistoryByUser(c.String("user"), from, to)
+	}
+	return errors.New("arguments error, required --ip or --user")
+}
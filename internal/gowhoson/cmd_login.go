@@ -3,6 +3,7 @@ package gowhoson
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/tai-ga/gowhoson/pkg/whoson"
 	"github.com/urfave/cli/v3"
@@ -20,14 +21,14 @@ func cmdLogin(ctx context.Context, c *cli.Command) error {
 
 	ip := c.Args().Slice()[0]
 	data := c.Args().Slice()[1]
-	client, err := whoson.Dial(config.Mode, config.Server)
+	client, err := dialClient(config)
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return err
 	}
 	defer client.Quit()
 
-	res, err := client.Login(ip, data)
+	res, err := client.LoginWithTTL(ip, data, time.Duration(c.Int("ttl"))*time.Second)
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return err
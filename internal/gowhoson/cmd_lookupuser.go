@@ -0,0 +1,38 @@
+package gowhoson
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tai-ga/gowhoson/pkg/whoson"
+	"github.com/urfave/cli/v3"
+)
+
+func cmdLookupUser(ctx context.Context, c *cli.Command) error {
+	config := c.Root().Metadata["config"].(*whoson.ServerCtlConfig)
+
+	if !c.Args().Present() || c.Args().Len() != 1 {
+		err := errors.New("arguments error, required 1 options")
+		displayError(c.Root().ErrWriter, err)
+		return err
+	}
+
+	if c.String("server") != "" {
+		config.Server = c.String("server")
+	}
+	if c.IsSet("json") {
+		config.JSON = c.Bool("json")
+	}
+
+	sc := whoson.NewServerCtl(config.Server)
+	sc.SetWriter(c.Root().Writer)
+	err := sc.LookupUser(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if config.JSON {
+		return sc.WriteJSON()
+	}
+	return sc.WriteTable()
+}
@@ -19,7 +19,7 @@ func cmdQuery(ctx context.Context, c *cli.Command) error {
 	}
 
 	ip := c.Args().Slice()[0]
-	client, err := whoson.Dial(config.Mode, config.Server)
+	client, err := dialClient(config)
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return err
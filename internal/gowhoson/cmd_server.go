@@ -3,13 +3,16 @@ package gowhoson
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -24,7 +27,7 @@ import (
 	"google.golang.org/grpc"
 )
 
-func signalHandler(ctx context.Context, ch <-chan os.Signal, wg *sync.WaitGroup, c *cli.Command, f func()) {
+func signalHandler(ctx context.Context, ch <-chan os.Signal, wg *sync.WaitGroup, reload func(), f func()) {
 	defer wg.Done()
 
 	for {
@@ -38,12 +41,10 @@ func signalHandler(ctx context.Context, ch <-chan os.Signal, wg *sync.WaitGroup,
 				if err != nil {
 					panic(err)
 				}
+				reloadTLSCertificate()
+				reload()
 			default:
 				f()
-				time.AfterFunc(time.Second*8, func() {
-					displayError(c.Root().ErrWriter, errors.New("clean shutdown took too long, forcing exit"))
-					os.Exit(0)
-				})
 			}
 		}
 	}
@@ -82,13 +83,18 @@ func ipportsValidate(c *cli.Command, optname string) (string, error) {
 
 func cmdServerValidate(c *cli.Command) (*whoson.ServerConfig, error) {
 	config := c.Root().Metadata["config"].(*whoson.ServerConfig)
+	return config, serverConfigValidate(c, config)
+}
+
+// serverConfigValidate override config by options, and validate it.
+func serverConfigValidate(c *cli.Command, config *whoson.ServerConfig) error {
 	if c.String("loglevel") != "" {
 		config.Loglevel = c.String("loglevel")
-		switch config.Loglevel {
-		case "debug", "info", "warn", "error", "dpanic", "panic", "fatal":
-		default:
-			return nil, fmt.Errorf("\"--loglevel %s\" not support loglevel", config.Loglevel)
-		}
+	}
+	switch config.Loglevel {
+	case "debug", "info", "warn", "error", "dpanic", "panic", "fatal":
+	default:
+		return fmt.Errorf("\"--loglevel %s\" not support loglevel", config.Loglevel)
 	}
 	if c.String("log") != "" {
 		config.Log = c.String("log")
@@ -123,14 +129,202 @@ func cmdServerValidate(c *cli.Command) (*whoson.ServerConfig, error) {
 
 	for _, opt := range []string{"tcp", "udp", "controlport", "syncremote"} {
 		if err := validatePortOption(opt); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	if c.String("savefile") != "" {
 		config.SaveFile = c.String("savefile")
 	}
-	return config, nil
+	return optionsValidate(c, config, ttlValidate, slidingValidate, authValidate, historyValidate, tlsValidate, unixValidate, tcpValidate, shutdownValidate, hookValidate, apiValidate, metricsValidate, tracingValidate)
+}
+
+type intOption struct {
+	name  string
+	value *int
+}
+
+func intOptionsValidate(c *cli.Command, opts []intOption) error {
+	for _, opt := range opts {
+		if c.Int(opt.name) != 0 {
+			*opt.value = c.Int(opt.name)
+		}
+		if *opt.value < 0 {
+			return fmt.Errorf("\"--%s %d\" must not be negative", opt.name, *opt.value)
+		}
+	}
+	return nil
+}
+
+func optionsValidate(c *cli.Command, config *whoson.ServerConfig, validators ...func(*cli.Command, *whoson.ServerConfig) error) error {
+	for _, validate := range validators {
+		if err := validate(c, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ttlValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	err := intOptionsValidate(c, []intOption{
+		{"ttldefault", &config.TTLDefault},
+		{"ttlmin", &config.TTLMin},
+		{"ttlmax", &config.TTLMax},
+	})
+	if err != nil {
+		return err
+	}
+	if config.TTLMax > 0 && config.TTLMin > config.TTLMax {
+		return fmt.Errorf("\"--ttlmin %d\" is greater than \"--ttlmax %d\"", config.TTLMin, config.TTLMax)
+	}
+	return nil
+}
+
+func slidingValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.IsSet("slidingexpire") {
+		config.SlidingExpire = c.Bool("slidingexpire")
+	}
+	return intOptionsValidate(c, []intOption{
+		{"slidinginterval", &config.SlidingInterval},
+		{"maxlifetime", &config.MaxLifetime},
+	})
+}
+
+func authValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.IsSet("authrequired") {
+		config.AuthRequired = c.Bool("authrequired")
+	}
+	if config.AuthRequired && len(config.AuthKeys) == 0 {
+		return errors.New("\"--authrequired\" needs AuthKeys in config file")
+	}
+	return intOptionsValidate(c, []intOption{
+		{"authwindow", &config.AuthWindow},
+	})
+}
+
+func historyValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.String("historyfile") != "" {
+		config.HistoryFile = c.String("historyfile")
+	}
+	return intOptionsValidate(c, []intOption{
+		{"historyretention", &config.HistoryRetention},
+	})
+}
+
+func tlsValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.String("tlscert") != "" {
+		config.TLSCert = c.String("tlscert")
+	}
+	if c.String("tlskey") != "" {
+		config.TLSKey = c.String("tlskey")
+	}
+	if c.String("tlsclientca") != "" {
+		config.TLSClientCA = c.String("tlsclientca")
+	}
+	if (config.TLSCert == "") != (config.TLSKey == "") {
+		return errors.New("\"--tlscert\" and \"--tlskey\" must be set together")
+	}
+	if config.TLSClientCA != "" && config.TLSCert == "" {
+		return errors.New("\"--tlsclientca\" needs \"--tlscert\" and \"--tlskey\"")
+	}
+	return nil
+}
+
+func unixValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.String("unix") != "" {
+		config.Unix = c.String("unix")
+	}
+	if c.String("unixgram") != "" {
+		config.Unixgram = c.String("unixgram")
+	}
+	if c.String("unixperm") != "" {
+		config.UnixPerm = c.String("unixperm")
+	}
+	if perm, err := strconv.ParseUint(config.UnixPerm, 8, 32); err != nil || perm > 0777 {
+		return fmt.Errorf("\"--unixperm %s\" must be octal permission", config.UnixPerm)
+	}
+	return nil
+}
+
+func tcpValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.IsSet("tcpkeepalive") {
+		config.TCPKeepAlive = c.Int("tcpkeepalive")
+	}
+	return intOptionsValidate(c, []intOption{
+		{"tcpidletimeout", &config.TCPIdleTimeout},
+		{"tcpreadtimeout", &config.TCPReadTimeout},
+		{"tcpmaxconns", &config.TCPMaxConns},
+		{"tcpmaxconnsperip", &config.TCPMaxConnsPerIP},
+	})
+}
+
+func shutdownValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	return intOptionsValidate(c, []intOption{
+		{"shutdowntimeout", &config.ShutdownTimeout},
+	})
+}
+
+func hookValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	return intOptionsValidate(c, []intOption{
+		{"hookworkers", &config.HookWorkers},
+		{"hooktimeout", &config.HookTimeout},
+		{"webhookworkers", &config.WebhookWorkers},
+		{"webhookretries", &config.WebhookRetries},
+		{"webhooktimeout", &config.WebhookTimeout},
+	})
+}
+
+func apiValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.String("api") != "" {
+		config.API = c.String("api")
+	}
+	if config.API == "" {
+		return nil
+	}
+	if _, _, err := splitHostPort(config.API); err != nil {
+		return fmt.Errorf("\"--api %s\" parse error", config.API)
+	}
+	if config.AuthRequired && len(config.APITokens) == 0 {
+		return errors.New("\"--api\" with AuthRequired needs APITokens in config file")
+	}
+	return nil
+}
+
+func metricsValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.String("metrics") != "" {
+		config.Metrics = c.String("metrics")
+	}
+	if c.String("metricspath") != "" {
+		config.MetricsPath = c.String("metricspath")
+	}
+	if config.Metrics != "" {
+		if _, _, err := splitHostPort(config.Metrics); err != nil {
+			return fmt.Errorf("\"--metrics %s\" parse error", config.Metrics)
+		}
+	}
+	if !strings.HasPrefix(config.MetricsPath, "/") || config.MetricsPath == expvarPath {
+		return fmt.Errorf("\"--metricspath %s\" must start with \"/\" and differ from %s", config.MetricsPath, expvarPath)
+	}
+	return nil
+}
+
+func tracingValidate(c *cli.Command, config *whoson.ServerConfig) error {
+	if c.String("tracing") != "" {
+		config.Tracing = c.String("tracing")
+	}
+	if c.String("tracingendpoint") != "" {
+		config.TracingEndpoint = c.String("tracingendpoint")
+	}
+	switch config.Tracing {
+	case "", whoson.TracingOTLP:
+	case whoson.TracingFile:
+		if config.TracingEndpoint == "" {
+			return fmt.Errorf("\"--tracing %s\" needs file path of --tracingendpoint", config.Tracing)
+		}
+	default:
+		return fmt.Errorf("\"--tracing %s\" not support tracing", config.Tracing)
+	}
+	return nil
 }
 
 func cmdServer(ctx context.Context, c *cli.Command) error {
@@ -145,38 +339,40 @@ func cmdServer(ctx context.Context, c *cli.Command) error {
 	sigChan := make(chan os.Signal, 1)
 	defer close(sigChan)
 
-	whoson.NewMainStoreEnableSyncRemote()
-	err = loadStore(config.SaveFile)
+	err = initServer(config)
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return err
 	}
-	err = whoson.NewLogger(config.Log, config.Loglevel)
+
+	shutdownTracing, err := whoson.InitTracing(context.Background(), config)
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return err
 	}
-	whoson.Log("info", fmt.Sprintf("ServerID:%d", config.ServerID), nil, nil)
-	whoson.NewIDGenerator(uint(config.ServerID))
+	defer stopTracing(c, shutdownTracing)
 
-	var con *net.UDPConn
-	if config.UDP != "nostart" {
-		con, err = runUDPServer(c, config, wg)
-		if err != nil {
-			return err
-		}
+	sockets, err := listenFDs()
+	if err != nil {
+		displayError(c.Root().ErrWriter, err)
+		return err
 	}
 
-	var lis *net.TCPListener
-	if config.TCP != "nostart" {
-		lis, err = runTCPServer(c, config, wg)
-		if err != nil {
-			return err
-		}
+	servers, err := runServers(c, config, wg, sockets)
+	if err != nil {
+		return err
+	}
+
+	api, err := runAPIServer(c, config, wg, sockets)
+	if err != nil {
+		return err
+	}
+	if api != nil {
+		servers = append(servers, api)
 	}
 
 	var lishttp net.Listener
-	lishttp, err = runExpvar(config, wg, c)
+	lishttp, err = runMetrics(config, wg, c, sockets)
 	if err != nil {
 		return err
 	}
@@ -191,7 +387,9 @@ func cmdServer(ctx context.Context, c *cli.Command) error {
 
 	zapLogger := &zapLoggerAdapter{logger: whoson.Logger}
 
+	// g.Stop wait for handlers, not to update the store after the sync loop exits.
 	g = grpc.NewServer(
+		grpc.WaitForHandlers(true),
 		grpc.ChainUnaryInterceptor(
 			logging.UnaryServerInterceptor(zapLogger, logOpts...),
 		),
@@ -199,12 +397,58 @@ func cmdServer(ctx context.Context, c *cli.Command) error {
 			logging.StreamServerInterceptor(zapLogger, logOpts...),
 		),
 	)
-	lisgrpc, err = runGrpc(g, config, wg, c)
+	lisgrpc, err = runGrpc(g, config, wg, c, sockets)
 	if err != nil {
 		return err
 	}
 
 	ctx, ctxCancel := context.WithCancel(context.Background())
+	runWorkers(ctx, wg, config)
+
+	wg.Add(1)
+	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	go signalHandler(ctx, sigChan, wg, func() {
+		config = reloadConfig(c, config)
+	}, func() {
+		// workers and the sync loop are stopped after all servers stopped.
+		defer ctxCancel()
+		sdNotify("STOPPING=1")
+		shutdownServers(c, servers, shutdownTimeout(config))
+		if lishttp != nil {
+			lishttp.Close()
+		}
+		lisgrpc.Close()
+		g.Stop()
+
+		err = saveStore(config.SaveFile)
+		if err != nil {
+			displayError(c.Root().ErrWriter, err)
+		}
+	})
+
+	wg.Wait()
+	return nil
+}
+
+// shutdownTimeout return grace period of ShutdownTimeout, or default when it is not positive.
+func shutdownTimeout(config *whoson.ServerConfig) time.Duration {
+	if config.ShutdownTimeout <= 0 {
+		return whoson.ShutdownTimeout
+	}
+	return time.Duration(config.ShutdownTimeout) * time.Second
+}
+
+// stopTracing flush spans left in exporter on shutdown.
+func stopTracing(c *cli.Command, shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), whoson.ShutdownTimeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		displayError(c.Root().ErrWriter, err)
+	}
+}
+
+// runWorkers start background goroutines of server, and notify systemd of ready.
+func runWorkers(ctx context.Context, wg *sync.WaitGroup, config *whoson.ServerConfig) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -214,84 +458,425 @@ func cmdServer(ctx context.Context, c *cli.Command) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if config.SyncRemote != "" {
-			hosts := strings.Split(config.SyncRemote, ",")
-			whoson.RunSyncRemote(ctx, hosts)
-		}
+		whoson.RunHistoryPurger(ctx)
 	}()
 
 	wg.Add(1)
-	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	go signalHandler(ctx, sigChan, wg, c, func() {
-		defer ctxCancel()
-		if config.UDP != "nostart" {
-			con.Close()
+	go func() {
+		defer wg.Done()
+		whoson.RunSyncRemote(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		whoson.RunHookWorkers(ctx, config.HookWorkers)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		whoson.RunWebhookWorkers(ctx, config.WebhookWorkerCount())
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runWatchdog(ctx)
+	}()
+
+	if err := sdNotify("READY=1"); err != nil {
+		whoson.Log("error", "sdNotify:Error", nil, err)
+	}
+}
+
+func initServer(config *whoson.ServerConfig) error {
+	whoson.NewMainStoreEnableSyncRemote()
+	err := loadStore(config.SaveFile)
+	if err != nil {
+		return err
+	}
+	err = whoson.NewLogger(config.Log, config.Loglevel)
+	if err != nil {
+		return err
+	}
+	whoson.Log("info", fmt.Sprintf("ServerID:%d", config.ServerID), nil, nil)
+	whoson.NewIDGenerator(uint(config.ServerID))
+	if config.HistoryFile != "" {
+		err = whoson.NewMainHistory(config.HistoryFile, time.Duration(config.HistoryRetention)*time.Second)
+		if err != nil {
+			return err
+		}
+	}
+	return whoson.SetServerConfig(config)
+}
+
+// server is a whoson server stopped gracefully.
+type server interface {
+	Shutdown(ctx context.Context) error
+}
+
+// shutdownServers stop servers in parallel, waiting sessions for grace period.
+func shutdownServers(c *cli.Command, servers []server, grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Shutdown(ctx); err != nil {
+				displayError(c.Root().ErrWriter, fmt.Errorf("clean shutdown took too long, sessions are closed: %v", err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runServers start whoson servers, and return them to shutdown.
+func runServers(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup, sockets *activatedSockets) ([]server, error) {
+	if sockets.hasRole(sdRoleWhoson) {
+		return runActivatedServers(c, config, wg, sockets)
+	}
+	var servers []server
+	if config.UDP != "nostart" {
+		s, err := runUDPServer(c, config, wg)
+		if err != nil {
+			return nil, err
 		}
-		if config.TCP != "nostart" {
-			lis.Close()
+		servers = append(servers, s...)
+	}
+	if config.TCP != "nostart" {
+		s, err := runTCPServer(c, config, wg)
+		if err != nil {
+			return nil, err
 		}
-		if config.Expvar {
-			lishttp.Close()
+		servers = append(servers, s...)
+	}
+	if config.Unix != "" {
+		s, err := runUnixServer(c, config, wg)
+		if err != nil {
+			return nil, err
 		}
-		lisgrpc.Close()
-		g.Stop()
+		servers = append(servers, s)
+	}
+	if config.Unixgram != "" {
+		s, err := runUnixgramServer(c, config, wg)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
 
-		err = saveStore(config.SaveFile)
+// runActivatedServers serve whoson sockets passed by systemd instead of binding sockets of config.
+func runActivatedServers(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup, sockets *activatedSockets) ([]server, error) {
+	var servers []server
+	tlsConfig, err := newServerTLSConfig(config)
+	if err != nil {
+		displayError(c.Root().ErrWriter, err)
+		return nil, err
+	}
+	for _, lis := range sockets.listeners[sdRoleWhoson] {
+		s := whoson.NewTCPServer()
+		if _, ok := lis.(*net.TCPListener); ok {
+			s.TLSConfig = tlsConfig
+		}
+		wg.Add(1)
+		go func(lis net.Listener) {
+			defer wg.Done()
+			s.Serve(lis)
+		}(lis)
+		servers = append(servers, s)
+	}
+	for _, con := range sockets.packetConns[sdRoleWhoson] {
+		s := whoson.NewUDPServer()
+		wg.Add(1)
+		go func(con net.PacketConn) {
+			defer wg.Done()
+			s.ServePacket(con)
+		}(con)
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+func runUDPServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup) ([]server, error) {
+	var servers []server
+	for _, addr := range strings.Split(config.UDP, ",") {
+		host, port, err := splitHostPort(strings.TrimSpace(addr))
 		if err != nil {
 			displayError(c.Root().ErrWriter, err)
+			return nil, err
 		}
-	})
-
-	wg.Wait()
-	return nil
+		addrudp := net.UDPAddr{
+			Port: port,
+			IP:   net.ParseIP(host),
+		}
+		con, err := net.ListenUDP("udp", &addrudp)
+		if err != nil {
+			displayError(c.Root().ErrWriter, err)
+			return nil, err
+		}
+		s := whoson.NewUDPServer()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.ServeUDP(con)
+		}()
+		servers = append(servers, s)
+	}
+	return servers, nil
 }
 
-func runUDPServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup) (*net.UDPConn, error) {
-	host, port, err := splitHostPort(config.UDP)
+func runTCPServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup) ([]server, error) {
+	tlsConfig, err := newServerTLSConfig(config)
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return nil, err
 	}
-	addrudp := net.UDPAddr{
-		Port: port,
-		IP:   net.ParseIP(host),
+	var servers []server
+	for _, addr := range strings.Split(config.TCP, ",") {
+		host, port, err := splitHostPort(strings.TrimSpace(addr))
+		if err != nil {
+			displayError(c.Root().ErrWriter, err)
+			return nil, err
+		}
+		addrtcp := net.TCPAddr{
+			Port: port,
+			IP:   net.ParseIP(host),
+		}
+		lis, err := net.ListenTCP("tcp", &addrtcp)
+		if err != nil {
+			displayError(c.Root().ErrWriter, err)
+			return nil, err
+		}
+		s := whoson.NewTCPServer()
+		s.TLSConfig = tlsConfig
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.ServeTCP(lis)
+		}()
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// tlsCertificate is TLS certificate of TCP server, reloaded by SIGHUP.
+var tlsCertificate *whoson.TLSCertificate
+
+func newServerTLSConfig(config *whoson.ServerConfig) (*tls.Config, error) {
+	if config.TLSCert == "" {
+		return nil, nil
+	}
+	cert, err := whoson.NewTLSCertificate(config.TLSCert, config.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsCertificate = cert
+	return whoson.NewServerTLSConfig(cert, config.TLSClientCA)
+}
+
+func reloadTLSCertificate() {
+	if tlsCertificate == nil {
+		return
+	}
+	if err := tlsCertificate.Reload(); err != nil {
+		whoson.Log("error", "reloadTLSCertificate:Error", nil, err)
+		return
+	}
+	whoson.Log("info", "reloadTLSCertificate", nil, nil)
+}
+
+// rebindOptions are config fields which need restart to change.
+var rebindOptions = []string{
+	"TCP", "UDP", "Unix", "Unixgram", "UnixPerm", "ControlPort", "Expvar",
+	"Log", "ServerID", "SaveFile", "HistoryFile", "HistoryRetention",
+	"TLSCert", "TLSKey", "TLSClientCA", "HookWorkers", "WebhookWorkers", "API",
+	"Metrics", "MetricsPath", "Tracing", "TracingEndpoint",
+}
+
+// keepRebindOptions set rebindOptions of config back to current, and return
+// names of changed options.
+func keepRebindOptions(current, config *whoson.ServerConfig) []string {
+	var ignored []string
+	cv := reflect.ValueOf(current).Elem()
+	nv := reflect.ValueOf(config).Elem()
+	for _, name := range rebindOptions {
+		if cv.FieldByName(name).Interface() != nv.FieldByName(name).Interface() {
+			ignored = append(ignored, name)
+			nv.FieldByName(name).Set(cv.FieldByName(name))
+		}
+	}
+	return ignored
+}
+
+// reloadConfig read config file again, and apply changes without rebinding.
+// current config is kept and returned when the file is invalid.
+func reloadConfig(c *cli.Command, current *whoson.ServerConfig) *whoson.ServerConfig {
+	file, config, err := GetServerConfig(c)
+	if err == nil {
+		err = serverConfigValidate(c, config)
 	}
-	var con *net.UDPConn
-	con, err = net.ListenUDP("udp", &addrudp)
+	if err != nil {
+		whoson.Log("error", "reloadConfig:Rejected", nil, err)
+		return current
+	}
+	ignored := keepRebindOptions(current, config)
+	if err := whoson.SetServerConfig(config); err != nil {
+		whoson.Log("error", "reloadConfig:Rejected", nil, err)
+		return current
+	}
+	whoson.SetLogLevel(config.Loglevel)
+	if len(ignored) > 0 {
+		whoson.Log("warn", fmt.Sprintf("reloadConfig:Ignored %s need restart", strings.Join(ignored, ",")), nil, nil)
+	}
+	whoson.Log("info", fmt.Sprintf("reloadConfig:Applied %s", file), nil, nil)
+	return config
+}
+
+// removeStaleSocket remove socket file left by previous server.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+func unixPerm(config *whoson.ServerConfig) os.FileMode {
+	perm, _ := strconv.ParseUint(config.UnixPerm, 8, 32)
+	return os.FileMode(perm)
+}
+
+func runUnixServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup) (server, error) {
+	if err := removeStaleSocket(config.Unix); err != nil {
+		displayError(c.Root().ErrWriter, err)
+		return nil, err
+	}
+	lis, err := net.ListenUnix("unix", &net.UnixAddr{Name: config.Unix, Net: "unix"})
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return nil, err
 	}
+	if err := os.Chmod(config.Unix, unixPerm(config)); err != nil {
+		lis.Close()
+		displayError(c.Root().ErrWriter, err)
+		return nil, err
+	}
+	s := whoson.NewTCPServer()
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		whoson.ServeUDP(con)
+		s.Serve(lis)
 	}()
-	return con, nil
+	return s, nil
 }
 
-func runTCPServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup) (*net.TCPListener, error) {
-	var lis *net.TCPListener
-	host, port, err := splitHostPort(config.TCP)
-	if err != nil {
+// unixgramServer remove socket file on Shutdown.
+type unixgramServer struct {
+	*whoson.UDPServer
+	path string
+}
+
+func (s *unixgramServer) Shutdown(ctx context.Context) error {
+	err := s.UDPServer.Shutdown(ctx)
+	os.Remove(s.path)
+	return err
+}
+
+func runUnixgramServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup) (server, error) {
+	if err := removeStaleSocket(config.Unixgram); err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return nil, err
 	}
-	addrtcp := net.TCPAddr{
-		Port: port,
-		IP:   net.ParseIP(host),
-	}
-	lis, err = net.ListenTCP("tcp", &addrtcp)
+	con, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: config.Unixgram, Net: "unixgram"})
 	if err != nil {
 		displayError(c.Root().ErrWriter, err)
 		return nil, err
 	}
+	if err := os.Chmod(config.Unixgram, unixPerm(config)); err != nil {
+		con.Close()
+		os.Remove(config.Unixgram)
+		displayError(c.Root().ErrWriter, err)
+		return nil, err
+	}
+	s := &unixgramServer{UDPServer: whoson.NewUDPServer(), path: config.Unixgram}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		whoson.ServeTCP(lis)
+		s.ServePacket(con)
 	}()
-	return lis, nil
+	return s, nil
+}
+
+// runAPIServer start HTTP API server, nil is returned when API is not configured.
+func runAPIServer(c *cli.Command, config *whoson.ServerConfig, wg *sync.WaitGroup, sockets *activatedSockets) (server, error) {
+	var err error
+	lis := sockets.listener(sdRoleAPI)
+	if lis == nil && config.API != "" {
+		if lis, err = getListener(c, config.API); err != nil {
+			return nil, err
+		}
+	}
+	if lis == nil {
+		return nil, nil
+	}
+	s := &apiServer{handler: whoson.NewAPIHandler()}
+	s.Server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: whoson.SessionTimeOut,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Serve(lis)
+	}()
+	return s, nil
+}
+
+// apiServer is http.Server of API, which Shutdown return after running
+// handlers finished, so that the store is not updated after the sync loop exits.
+type apiServer struct {
+	*http.Server
+	handler  http.Handler
+	mu       sync.Mutex
+	closed   bool
+	handlers sync.WaitGroup
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	s.handlers.Add(1)
+	s.mu.Unlock()
+	defer s.handlers.Done()
+	s.handler.ServeHTTP(w, r)
+}
+
+// Shutdown stop server gracefully until ctx is done, then close connections
+// and wait for running handlers.
+func (s *apiServer) Shutdown(ctx context.Context) error {
+	err := s.Server.Shutdown(ctx)
+	if err != nil {
+		s.Server.Close()
+	}
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.handlers.Wait()
+	return err
 }
 
 func getListener(c *cli.Command, host string) (net.Listener, error) {
@@ -303,32 +888,53 @@ func getListener(c *cli.Command, host string) (net.Listener, error) {
 	return l, nil
 }
 
-func runExpvar(config *whoson.ServerConfig, wg *sync.WaitGroup, c *cli.Command) (net.Listener, error) {
-	var lishttp net.Listener
+// runMetrics serve Prometheus metrics and expvar on the metrics socket, Metrics, or ":8080" of Expvar.
+func runMetrics(config *whoson.ServerConfig, wg *sync.WaitGroup, c *cli.Command, sockets *activatedSockets) (net.Listener, error) {
 	var err error
-	if config.Expvar {
+	lishttp := sockets.listener(sdRoleMetrics)
+	if lishttp == nil && config.Metrics != "" {
+		if lishttp, err = getListener(c, config.Metrics); err != nil {
+			return nil, err
+		}
+	}
+	if lishttp == nil && config.Expvar {
 		if lishttp, err = getListener(c, ":8080"); err != nil {
 			return nil, err
 		}
+	}
+	if lishttp != nil {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			http.Serve(lishttp, nil)
+			http.Serve(lishttp, newMetricsMux(config.MetricsPath))
 		}()
 	}
 	return lishttp, nil
 }
 
-func runGrpc(g *grpc.Server, config *whoson.ServerConfig, wg *sync.WaitGroup, c *cli.Command) (net.Listener, error) {
-	var lisgrpc net.Listener
+func newMetricsMux(path string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(expvarPath, expvar.Handler())
+	mux.Handle(path, whoson.NewMetricsHandler())
+	return mux
+}
+
+func runGrpc(g *grpc.Server, config *whoson.ServerConfig, wg *sync.WaitGroup, c *cli.Command, sockets *activatedSockets) (net.Listener, error) {
 	var err error
-	if lisgrpc, err = getListener(c, config.ControlPort); err != nil {
-		return nil, err
+	lisgrpc := sockets.listener(sdRoleControl)
+	if lisgrpc == nil {
+		if lisgrpc, err = getListener(c, config.ControlPort); err != nil {
+			return nil, err
+		}
+	}
+	if config.AuthRequired && len(config.APITokens) == 0 {
+		whoson.Log("warn", "runGrpc:Login,Logout,Refresh of whoson service rejected, AuthRequired needs APITokens", nil, nil)
 	}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		whoson.RegisterSyncServer(g, &whoson.Sync{})
+		whoson.RegisterWhosonServer(g, &whoson.Whoson{})
 		g.Serve(lisgrpc)
 	}()
 	return lisgrpc, nil
@@ -354,7 +960,7 @@ func loadStore(f string) error {
 	}
 	for _, sd := range sds {
 		if sd.Expire.After(time.Now()) {
-			whoson.MainStore.SyncSet(sd.IP.String(), sd)
+			whoson.MainStore.SyncSet(sd.Key(), sd)
 		}
 	}
 	return nil
@@ -0,0 +1,176 @@
+package gowhoson
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tai-ga/gowhoson/pkg/whoson"
+	"github.com/urfave/cli/v3"
+)
+
+// runReloadConfig run reloadConfig with server command options of args.
+func runReloadConfig(t *testing.T, current *whoson.ServerConfig, args ...string) *whoson.ServerConfig {
+	var config *whoson.ServerConfig
+	AppVersions = NewVersions("", "")
+	app := makeApp()
+	app.Command("server").Action = func(_ context.Context, c *cli.Command) error {
+		config = reloadConfig(c, current)
+		return nil
+	}
+	if err := app.Run(context.Background(), append([]string{"gowhoson"}, args...)); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	return config
+}
+
+func TestReloadConfig(t *testing.T) {
+	whoson.NewLogger("discard", "error")
+	defer whoson.SetServerConfig(nil)
+	file := filepath.Join(t.TempDir(), "gowhoson.json")
+	current := &whoson.ServerConfig{
+		TCP:      "127.0.0.1:9876",
+		UDP:      "127.0.0.1:9876",
+		Loglevel: "error",
+		TTLMax:   3600,
+	}
+
+	var tests = []struct {
+		json    string
+		args    []string
+		applied bool
+	}{
+		{`{"TCP": "0.0.0.0:9876", "Loglevel": "info", "TTLMax": 7200}`, nil, true},
+		{`{"TTLMax": 7200}`, []string{"--ttlmax", "60"}, true},
+		{`{"TTLMax": `, nil, false},
+		{`{"Loglevel": "verbose"}`, nil, false},
+		{`{"ACL": [{"Network": "192.0.2.0/33", "Methods": ["ALL"]}]}`, nil, false},
+		{`{"MetricsPath": "/debug/vars"}`, nil, false},
+		{`{}`, []string{"--metrics", "127.0.0.1"}, false},
+		{`{"Tracing": "file"}`, nil, false},
+		{`{"Tracing": "jaeger"}`, nil, false},
+		{`{"API": "127.0.0.1:8081", "AuthRequired": true, "AuthKeys": {"k1": "s3cr3t"}}`, nil, false},
+		{`{"API": "127.0.0.1:8081", "AuthRequired": true, "AuthKeys": {"k1": "s3cr3t"}, "APITokens": {"portal": "t0ken"}}`, nil, true},
+	}
+	for i, tt := range tests {
+		if err := os.WriteFile(file, []byte(tt.json), 0644); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		args := append([]string{"--config", file, "server"}, tt.args...)
+		config := runReloadConfig(t, current, args...)
+		if !tt.applied {
+			if config != current {
+				t.Fatalf("%d: current config should be kept, actual %+v", i, config)
+			}
+			continue
+		}
+		if config.TCP != current.TCP {
+			t.Fatalf("%d: TCP should be ignored, actual %v", i, config.TCP)
+		}
+		if config.TTLMax == current.TTLMax {
+			t.Fatalf("%d: TTLMax should be applied, actual %v", i, config.TTLMax)
+		}
+	}
+}
+
+func TestKeepRebindOptions(t *testing.T) {
+	current := &whoson.ServerConfig{TCP: "127.0.0.1:9876", ServerID: 1, TTLMax: 60}
+	config := &whoson.ServerConfig{TCP: "0.0.0.0:9876", ServerID: 2, TTLMax: 120}
+	ignored := keepRebindOptions(current, config)
+	if len(ignored) != 2 || ignored[0] != "TCP" || ignored[1] != "ServerID" {
+		t.Fatalf("expected [TCP ServerID], actual %v", ignored)
+	}
+	if config.TCP != current.TCP || config.ServerID != current.ServerID || config.TTLMax != 120 {
+		t.Fatalf("rebind options should be kept, actual %+v", config)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var tests = []struct {
+		timeout  int
+		expected time.Duration
+	}{
+		{0, whoson.ShutdownTimeout},
+		{-1, whoson.ShutdownTimeout},
+		{30, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if actual := shutdownTimeout(&whoson.ServerConfig{ShutdownTimeout: tt.timeout}); actual != tt.expected {
+			t.Fatalf("%d: expected %v, actual %v", tt.timeout, tt.expected, actual)
+		}
+	}
+}
+
+func TestAPIServer_Shutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := &apiServer{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	s.Server = &http.Server{Handler: s}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	go s.Serve(lis)
+	go http.Get("http://" + lis.Addr().String() + "/")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(ctx) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown should wait for running handler, actual %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if err := <-done; err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, actual %v", context.DeadlineExceeded, err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %d after shutdown, actual %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
+
+func TestNewMetricsMux(t *testing.T) {
+	whoson.NewMainStore()
+	ts := httptest.NewServer(newMetricsMux("/prom"))
+	defer ts.Close()
+
+	var tests = []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/prom", http.StatusOK, "gowhoson_store_records"},
+		{expvarPath, http.StatusOK, `"gowhoson"`},
+		{"/metrics", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(ts.URL + tt.path)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if resp.StatusCode != tt.status || !strings.Contains(string(b), tt.body) {
+			t.Fatalf("%s: expected %d with %q, actual %d %q", tt.path, tt.status, tt.body, resp.StatusCode, b)
+		}
+	}
+}
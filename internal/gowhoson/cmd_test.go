@@ -80,11 +80,14 @@ func TestCmd(t *testing.T) {
 		func(app *cli.Command) {
 			app.Run(context.Background(), []string{"gowhoson", "client", "query", "1.1.1.1"})
 		},
+		func(app *cli.Command) {
+			app.Run(context.Background(), []string{"gowhoson", "client", "refresh", "--ttl", "3600", "1.1.1.1"})
+		},
 		func(app *cli.Command) {
 			app.Run(context.Background(), []string{"gowhoson", "client", "logout", "1.1.1.1"})
 		},
 	)
-	for _, s := range []string{"+LOGIN OK", "+TESTSTRING", "+LOGOUT record deleted"} {
+	for _, s := range []string{"+LOGIN OK", "+TESTSTRING", "+REFRESH OK", "+LOGOUT record deleted"} {
 		if !strings.Contains(out, s) {
 			t.Fatalf("%q should be contained in output of command: %v", s, out)
 		}
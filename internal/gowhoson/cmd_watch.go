@@ -0,0 +1,93 @@
+package gowhoson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tai-ga/gowhoson/pkg/whoson"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const watchTableFormat = "%-19s %-8s %-6s %-24s %-19s %s\n"
+
+func cmdWatch(ctx context.Context, c *cli.Command) error {
+	config := c.Root().Metadata["config"].(*whoson.ServerCtlConfig)
+
+	if c.String("server") != "" {
+		config.Server = c.String("server")
+	}
+	if c.IsSet("json") {
+		config.JSON = c.Bool("json")
+	}
+	if c.String("apitoken") != "" {
+		config.APIToken = c.String("apitoken")
+	}
+
+	var opts []grpc.DialOption
+	if config.APIToken != "" {
+		opts = append(opts, whoson.WithAPIToken(config.APIToken))
+	}
+	client, err := whoson.NewControlClient(config.Server, opts...)
+	if err != nil {
+		displayError(c.Root().ErrWriter, err)
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stream, err := client.Watch(ctx, &whoson.WSWatchRequest{
+		Networks: c.StringSlice("network"),
+		Data:     c.String("user"),
+		Snapshot: c.Bool("snapshot"),
+	})
+	if err == nil {
+		err = writeWatchEvents(c.Root().Writer, stream.Recv, config.JSON)
+	}
+	if err != nil && status.Code(err) != codes.Canceled {
+		displayError(c.Root().ErrWriter, err)
+		return err
+	}
+	return nil
+}
+
+// writeWatchEvents write events received by recv in table or JSON lines, until recv returns error.
+func writeWatchEvents(w io.Writer, recv func() (*whoson.WSEvent, error), jsonOut bool) error {
+	if !jsonOut {
+		fmt.Fprintf(w, watchTableFormat, "Time", "Event", "Source", "IP", "Expire", "Data")
+	}
+	enc := json.NewEncoder(w)
+	for {
+		ev, err := recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if jsonOut {
+			err = enc.Encode(ev)
+		} else {
+			_, err = fmt.Fprintf(w, watchTableFormat, watchTime(ev.Time), ev.Event, ev.Source,
+				ev.Record.GetIP(), watchTime(ev.Record.GetExpire()), ev.Record.GetData())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func watchTime(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).Format(historyTimeLayout)
+}
@@ -0,0 +1,56 @@
+package gowhoson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tai-ga/gowhoson/pkg/whoson"
+)
+
+func TestWriteWatchEvents(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local).Unix()
+	newRecv := func(err error) func() (*whoson.WSEvent, error) {
+		events := []*whoson.WSEvent{
+			{Time: now, Event: "Login", Source: "local", Record: &whoson.WSRecord{IP: "192.0.2.1", Data: "user1", Expire: now + 60}},
+		}
+		return func() (*whoson.WSEvent, error) {
+			if len(events) == 0 {
+				return nil, err
+			}
+			ev := events[0]
+			events = events[1:]
+			return ev, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeWatchEvents(&buf, newRecv(io.EOF), false); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "Time") {
+		t.Fatalf("unexpected table %q", buf.String())
+	}
+	for _, s := range []string{"2024-01-01 00:00:00", "Login", "local", "192.0.2.1", "2024-01-01 00:01:00", "user1"} {
+		if !strings.Contains(lines[1], s) {
+			t.Fatalf("%q not found in %q", s, lines[1])
+		}
+	}
+
+	buf.Reset()
+	if err := writeWatchEvents(&buf, newRecv(io.EOF), true); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"Time":`) || !strings.Contains(buf.String(), `"IP":"192.0.2.1"`) {
+		t.Fatalf("unexpected JSON %q", buf.String())
+	}
+
+	want := errors.New("stream error")
+	if err := writeWatchEvents(io.Discard, newRecv(want), true); err != want {
+		t.Fatalf("expected %v, actual %v", want, err)
+	}
+}
@@ -7,4 +7,7 @@ const (
 	ServerConfig = "gowhoson.json"
 	// ServerCtlConfig is a config file name for server control.
 	ServerCtlConfig = "serverctl.json"
+
+	// expvarPath is a path of expvar on the metrics port.
+	expvarPath = "/debug/vars"
 )
@@ -53,7 +53,7 @@ func makeApp() *cli.Command {
 	clientFlags := []cli.Flag{
 		&cli.StringFlag{
 			Name:    "mode",
-			Usage:   "e.g. [tcp|udp]",
+			Usage:   "e.g. [tcp|udp|tls|unix|unixgram]",
 			Sources: cli.EnvVars("GOWHOSON_CLIENT_MODE"),
 		},
 		&cli.StringFlag{
@@ -61,6 +61,31 @@ func makeApp() *cli.Command {
 			Usage:   "e.g. [ServerIP:Port]",
 			Sources: cli.EnvVars("GOWHOSON_CLIENT_SERVER"),
 		},
+		&cli.StringFlag{
+			Name:    "authkeyid",
+			Usage:   "key id for signed LOGIN/LOGOUT/REFRESH, e.g. [pop01]",
+			Sources: cli.EnvVars("GOWHOSON_CLIENT_AUTHKEYID"),
+		},
+		&cli.StringFlag{
+			Name:    "authkey",
+			Usage:   "shared secret key for signed LOGIN/LOGOUT/REFRESH",
+			Sources: cli.EnvVars("GOWHOSON_CLIENT_AUTHKEY"),
+		},
+		&cli.StringFlag{
+			Name:    "tlsca",
+			Usage:   "CA file to verify server certificate in tls mode (default: system roots)",
+			Sources: cli.EnvVars("GOWHOSON_CLIENT_TLSCA"),
+		},
+		&cli.StringFlag{
+			Name:    "tlscert",
+			Usage:   "client certificate file in tls mode",
+			Sources: cli.EnvVars("GOWHOSON_CLIENT_TLSCERT"),
+		},
+		&cli.StringFlag{
+			Name:    "tlskey",
+			Usage:   "client key file in tls mode",
+			Sources: cli.EnvVars("GOWHOSON_CLIENT_TLSKEY"),
+		},
 	}
 
 	app.Commands = []*cli.Command{
@@ -70,14 +95,29 @@ func makeApp() *cli.Command {
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:    "tcp",
-					Usage:   "e.g. [ServerIP:Port|nostart]",
+					Usage:   "e.g. [ServerIP:Port[,ServerIP:Port...]|nostart]",
 					Sources: cli.EnvVars("GOWHOSON_SERVER_TCP"),
 				},
 				&cli.StringFlag{
 					Name:    "udp",
-					Usage:   "e.g. [ServerIP:Port|nostart]",
+					Usage:   "e.g. [ServerIP:Port[,ServerIP:Port...]|nostart]",
 					Sources: cli.EnvVars("GOWHOSON_SERVER_UDP"),
 				},
+				&cli.StringFlag{
+					Name:    "unix",
+					Usage:   "unix stream socket path, e.g. \"/run/gowhoson/whoson.sock\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_UNIX"),
+				},
+				&cli.StringFlag{
+					Name:    "unixgram",
+					Usage:   "unix datagram socket path, e.g. \"/run/gowhoson/whoson.dgram\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_UNIXGRAM"),
+				},
+				&cli.StringFlag{
+					Name:    "unixperm",
+					Usage:   "permission of unix socket files, e.g. [0660]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_UNIXPERM"),
+				},
 				&cli.StringFlag{
 					Name:    "log",
 					Usage:   "e.g. [stdout|stderr|discard] or \"/var/log/filename.log\"",
@@ -113,6 +153,151 @@ func makeApp() *cli.Command {
 					Usage:   "e.g. [/var/lib/gowhoson.json]",
 					Sources: cli.EnvVars("GOWHOSON_SERVER_SAVEFILE"),
 				},
+				&cli.IntFlag{
+					Name:    "ttldefault",
+					Usage:   "LOGIN ttl seconds when client not specified, e.g. [1800]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TTLDEFAULT"),
+				},
+				&cli.IntFlag{
+					Name:    "ttlmin",
+					Usage:   "LOGIN ttl lower limit seconds, e.g. [60]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TTLMIN"),
+				},
+				&cli.IntFlag{
+					Name:    "ttlmax",
+					Usage:   "LOGIN ttl upper limit seconds, e.g. [86400]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TTLMAX"),
+				},
+				&cli.BoolFlag{
+					Name:    "slidingexpire",
+					Usage:   "QUERY extends record expire time, e.g. (default: false)",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_SLIDINGEXPIRE"),
+				},
+				&cli.IntFlag{
+					Name:    "slidinginterval",
+					Usage:   "minimum seconds of sliding extension to store and sync, e.g. [60]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_SLIDINGINTERVAL"),
+				},
+				&cli.IntFlag{
+					Name:    "maxlifetime",
+					Usage:   "upper limit seconds of sliding session lifetime from LOGIN, e.g. [43200]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_MAXLIFETIME"),
+				},
+				&cli.BoolFlag{
+					Name:    "authrequired",
+					Usage:   "reject unsigned LOGIN/LOGOUT/REFRESH, e.g. (default: false)",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_AUTHREQUIRED"),
+				},
+				&cli.IntFlag{
+					Name:    "authwindow",
+					Usage:   "allowed seconds of signed command timestamp difference, e.g. [60]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_AUTHWINDOW"),
+				},
+				&cli.StringFlag{
+					Name:    "historyfile",
+					Usage:   "login history file, e.g. \"/var/lib/gowhoson/history.json\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_HISTORYFILE"),
+				},
+				&cli.IntFlag{
+					Name:    "historyretention",
+					Usage:   "retention seconds of login history, e.g. [7776000]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_HISTORYRETENTION"),
+				},
+				&cli.StringFlag{
+					Name:    "tlscert",
+					Usage:   "certificate file of TLS on TCP, e.g. \"/etc/gowhoson/server.crt\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TLSCERT"),
+				},
+				&cli.StringFlag{
+					Name:    "tlskey",
+					Usage:   "key file of TLS on TCP, e.g. \"/etc/gowhoson/server.key\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TLSKEY"),
+				},
+				&cli.StringFlag{
+					Name:    "tlsclientca",
+					Usage:   "CA file to verify required client certificates, e.g. \"/etc/gowhoson/ca.crt\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TLSCLIENTCA"),
+				},
+				&cli.IntFlag{
+					Name:    "tcpidletimeout",
+					Usage:   "seconds to wait for next request on TCP, e.g. [10]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TCPIDLETIMEOUT"),
+				},
+				&cli.IntFlag{
+					Name:    "tcpreadtimeout",
+					Usage:   "seconds to read and answer a request on TCP, e.g. [10]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TCPREADTIMEOUT"),
+				},
+				&cli.IntFlag{
+					Name:    "tcpmaxconns",
+					Usage:   "maximum concurrent TCP connections, unlimited when 0, e.g. [1024]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TCPMAXCONNS"),
+				},
+				&cli.IntFlag{
+					Name:    "tcpmaxconnsperip",
+					Usage:   "maximum concurrent TCP connections per client IP, unlimited when 0, e.g. [16]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TCPMAXCONNSPERIP"),
+				},
+				&cli.IntFlag{
+					Name:    "tcpkeepalive",
+					Usage:   "TCP keepalive period seconds, system default when 0, disabled when negative, e.g. [60]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TCPKEEPALIVE"),
+				},
+				&cli.IntFlag{
+					Name:    "shutdowntimeout",
+					Usage:   "seconds to wait for active sessions on shutdown, e.g. [8]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_SHUTDOWNTIMEOUT"),
+				},
+				&cli.IntFlag{
+					Name:    "hookworkers",
+					Usage:   "number of goroutines running hook commands, e.g. [4]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_HOOKWORKERS"),
+				},
+				&cli.IntFlag{
+					Name:    "hooktimeout",
+					Usage:   "seconds to kill a hook command, e.g. [10]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_HOOKTIMEOUT"),
+				},
+				&cli.IntFlag{
+					Name:    "webhookworkers",
+					Usage:   "number of goroutines posting webhooks, hookworkers when 0, e.g. [4]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_WEBHOOKWORKERS"),
+				},
+				&cli.IntFlag{
+					Name:    "webhookretries",
+					Usage:   "number of retries of a failed webhook, e.g. [3]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_WEBHOOKRETRIES"),
+				},
+				&cli.IntFlag{
+					Name:    "webhooktimeout",
+					Usage:   "seconds to wait for a webhook response, e.g. [5]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_WEBHOOKTIMEOUT"),
+				},
+				&cli.StringFlag{
+					Name:    "api",
+					Usage:   "HTTP API listen address, e.g. \"127.0.0.1:9878\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_API"),
+				},
+				&cli.StringFlag{
+					Name:    "metrics",
+					Usage:   "Prometheus metrics and expvar listen address, e.g. \"127.0.0.1:9100\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_METRICS"),
+				},
+				&cli.StringFlag{
+					Name:    "metricspath",
+					Usage:   "path of Prometheus metrics, e.g. \"/metrics\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_METRICSPATH"),
+				},
+				&cli.StringFlag{
+					Name:    "tracing",
+					Usage:   "OpenTelemetry span exporter, e.g. [otlp|file]",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TRACING"),
+				},
+				&cli.StringFlag{
+					Name:    "tracingendpoint",
+					Usage:   "OTLP gRPC endpoint URL or file path of spans, e.g. \"http://127.0.0.1:4317\"",
+					Sources: cli.EnvVars("GOWHOSON_SERVER_TRACINGENDPOINT"),
+				},
 			},
 			Action: cmdServer,
 		},
@@ -121,9 +306,15 @@ func makeApp() *cli.Command {
 			Usage: "gowhoson client mode",
 			Commands: []*cli.Command{
 				{
-					Name:   "login",
-					Usage:  "whoson command \"LOGIN\"",
-					Flags:  clientFlags,
+					Name:  "login",
+					Usage: "whoson command \"LOGIN\"",
+					Flags: append([]cli.Flag{
+						&cli.IntFlag{
+							Name:    "ttl",
+							Usage:   "ttl seconds, e.g. [3600] (default: server setting)",
+							Sources: cli.EnvVars("GOWHOSON_CLIENT_TTL"),
+						},
+					}, clientFlags...),
 					Action: cmdLogin,
 				},
 				{
@@ -138,6 +329,18 @@ func makeApp() *cli.Command {
 					Flags:  clientFlags,
 					Action: cmdLogout,
 				},
+				{
+					Name:  "refresh",
+					Usage: "whoson command \"REFRESH\"",
+					Flags: append([]cli.Flag{
+						&cli.IntFlag{
+							Name:    "ttl",
+							Usage:   "ttl seconds, e.g. [3600] (default: record ttl)",
+							Sources: cli.EnvVars("GOWHOSON_CLIENT_TTL"),
+						},
+					}, clientFlags...),
+					Action: cmdRefresh,
+				},
 				{
 					Name:   "editconfig",
 					Usage:  "edit client configration file",
@@ -167,6 +370,94 @@ func makeApp() *cli.Command {
 			},
 			Action: cmdDump,
 		},
+		{
+			Name:  "lookup-user",
+			Usage: "gowhoson server control lookup IP addresses by user",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "server",
+					Usage:   "e.g. [ServerIP:Port]",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_LOOKUPUSER_SERVER"),
+				},
+				&cli.BoolFlag{
+					Name:    "json",
+					Usage:   "e.g. (default: false)",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_LOOKUPUSER_JSON"),
+				},
+			},
+			Action: cmdLookupUser,
+		},
+		{
+			Name:  "history",
+			Usage: "gowhoson server control login history of IP at time, or of user over time range",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "server",
+					Usage:   "e.g. [ServerIP:Port]",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_HISTORY_SERVER"),
+				},
+				&cli.BoolFlag{
+					Name:    "json",
+					Usage:   "e.g. (default: false)",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_HISTORY_JSON"),
+				},
+				&cli.StringFlag{
+					Name:  "ip",
+					Usage: "e.g. [192.0.2.1]",
+				},
+				&cli.StringFlag{
+					Name:  "user",
+					Usage: "e.g. [user01]",
+				},
+				&cli.StringFlag{
+					Name:  "time",
+					Usage: "time of --ip, e.g. [\"2006-01-02 15:04:05\"] (default: now)",
+				},
+				&cli.StringFlag{
+					Name:  "from",
+					Usage: "start time of --user, e.g. [\"2006-01-02 15:04:05\"] (default: 24 hours ago)",
+				},
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "end time of --user, e.g. [\"2006-01-02 15:04:05\"] (default: now)",
+				},
+			},
+			Action: cmdHistory,
+		},
+		{
+			Name:  "watch",
+			Usage: "gowhoson server control watch store change events",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "server",
+					Usage:   "e.g. [ServerIP:Port]",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_WATCH_SERVER"),
+				},
+				&cli.BoolFlag{
+					Name:    "json",
+					Usage:   "e.g. (default: false)",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_WATCH_JSON"),
+				},
+				&cli.StringFlag{
+					Name:    "apitoken",
+					Usage:   "token of APITokens of server",
+					Sources: cli.EnvVars("GOWHOSON_SERVERCTL_WATCH_APITOKEN"),
+				},
+				&cli.StringSliceFlag{
+					Name:  "network",
+					Usage: "watch only IP addresses in network, e.g. [192.0.2.0/24]",
+				},
+				&cli.StringFlag{
+					Name:  "user",
+					Usage: "watch only user, e.g. [user01]",
+				},
+				&cli.BoolFlag{
+					Name:  "snapshot",
+					Usage: "show stored data before events, e.g. (default: false)",
+				},
+			},
+			Action: cmdWatch,
+		},
 	}
 	return app
 }
@@ -181,23 +472,16 @@ func Run() int {
 	app := makeApp()
 
 	app.Before = func(ctx context.Context, c *cli.Command) (context.Context, error) {
-		if c.Args().Len() > 0 && c.Args().Slice()[0] == "client" {
-			err := runClient(ctx, c, app)
-			if err != nil {
-				return ctx, err
-			}
-		} else if c.Args().Len() > 0 && c.Args().Slice()[0] == "dump" {
-			err := runDump(ctx, c, app)
-			if err != nil {
-				return ctx, err
-			}
-		} else if c.Args().Len() > 0 && c.Args().Slice()[0] == "server" {
-			err := runServer(ctx, c, app)
-			if err != nil {
-				return ctx, err
-			}
+		var err error
+		switch c.Args().First() {
+		case "client":
+			err = runClient(ctx, c, app)
+		case "dump", "lookup-user", "history", "watch":
+			err = runDump(ctx, c, app)
+		case "server":
+			err = runServer(ctx, c, app)
 		}
-		return ctx, nil
+		return ctx, err
 	}
 
 	app.Run(context.Background(), os.Args)
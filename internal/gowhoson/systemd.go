@@ -0,0 +1,154 @@
+package gowhoson
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tai-ga/gowhoson/pkg/whoson"
+)
+
+const (
+	sdListenFdsStart = 3
+
+	// sdRoleWhoson is default role of sockets, other names than control, metrics and api are whoson.
+	sdRoleWhoson  = "whoson"
+	sdRoleControl = "control"
+	sdRoleMetrics = "metrics"
+	sdRoleAPI     = "api"
+)
+
+// activatedSockets hold sockets passed by systemd socket activation for each role.
+type activatedSockets struct {
+	listeners   map[string][]net.Listener
+	packetConns map[string][]net.PacketConn
+}
+
+// listenFDs return sockets passed by systemd with LISTEN_FDS and LISTEN_FDNAMES.
+func listenFDs() (*activatedSockets, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return newActivatedSockets(nil)
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return newActivatedSockets(nil)
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	files := make([]*os.File, n)
+	for i := range files {
+		fd := sdListenFdsStart + i
+		syscall.CloseOnExec(fd)
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		files[i] = os.NewFile(uintptr(fd), name)
+	}
+	return newActivatedSockets(files)
+}
+
+func newActivatedSockets(files []*os.File) (*activatedSockets, error) {
+	as := &activatedSockets{
+		listeners:   make(map[string][]net.Listener),
+		packetConns: make(map[string][]net.PacketConn),
+	}
+	for _, f := range files {
+		role := f.Name()
+		if role != sdRoleControl && role != sdRoleMetrics && role != sdRoleAPI {
+			role = sdRoleWhoson
+		}
+		sotype, err := syscall.GetsockoptInt(int(f.Fd()), syscall.SOL_SOCKET, syscall.SO_TYPE)
+		if err != nil {
+			return nil, err
+		}
+		if sotype == syscall.SOCK_DGRAM {
+			pc, err := net.FilePacketConn(f)
+			if err != nil {
+				return nil, err
+			}
+			as.packetConns[role] = append(as.packetConns[role], pc)
+		} else {
+			l, err := net.FileListener(f)
+			if err != nil {
+				return nil, err
+			}
+			as.listeners[role] = append(as.listeners[role], l)
+		}
+		f.Close()
+	}
+	return as, nil
+}
+
+// listener return first stream socket of role, nil when not passed.
+func (as *activatedSockets) listener(role string) net.Listener {
+	if len(as.listeners[role]) == 0 {
+		return nil
+	}
+	return as.listeners[role][0]
+}
+
+// hasRole return true when any socket of role is passed.
+func (as *activatedSockets) hasRole(role string) bool {
+	return len(as.listeners[role]) > 0 || len(as.packetConns[role]) > 0
+}
+
+// sdNotify send state to systemd, nothing is done without NOTIFY_SOCKET.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdWatchdogInterval return interval of WATCHDOG=1, zero when watchdog is disabled.
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runWatchdog send WATCHDOG=1 while the store responds, so that systemd
+// restarts the server when the store is wedged.
+func runWatchdog(ctx context.Context) {
+	interval := sdWatchdogInterval()
+	if interval == 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := whoson.CheckStore(interval); err != nil {
+				whoson.Log("error", "runWatchdog:Error", nil, err)
+				continue
+			}
+			if err := sdNotify("WATCHDOG=1"); err != nil {
+				whoson.Log("error", "runWatchdog:Error", nil, err)
+			}
+		}
+	}
+}
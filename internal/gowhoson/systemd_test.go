@@ -0,0 +1,135 @@
+package gowhoson
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestActivatedSockets(t *testing.T) {
+	tl, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer tl.Close()
+	uc, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer uc.Close()
+	cl, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer cl.Close()
+
+	files := []*os.File{
+		namedFile(t, tl.File, "gowhoson.socket"),
+		namedFile(t, uc.File, ""),
+		namedFile(t, cl.File, sdRoleControl),
+	}
+	as, err := newActivatedSockets(files)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(as.listeners[sdRoleWhoson]) != 1 || len(as.packetConns[sdRoleWhoson]) != 1 {
+		t.Fatalf("whoson sockets %v %v", as.listeners, as.packetConns)
+	}
+	if l := as.listener(sdRoleControl); l == nil || l.Addr().String() != cl.Addr().String() {
+		t.Fatalf("control listener %v, want %v", l, cl.Addr())
+	}
+	if as.listener(sdRoleMetrics) != nil || as.hasRole(sdRoleMetrics) {
+		t.Fatalf("metrics listener should not be passed")
+	}
+	for _, l := range as.listeners[sdRoleWhoson] {
+		l.Close()
+	}
+	for _, l := range as.listeners[sdRoleControl] {
+		l.Close()
+	}
+	for _, c := range as.packetConns[sdRoleWhoson] {
+		c.Close()
+	}
+}
+
+// namedFile return duplicated socket file named like LISTEN_FDNAMES.
+func namedFile(t *testing.T, file func() (*os.File, error), name string) *os.File {
+	f, err := file()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer f.Close()
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	return os.NewFile(uintptr(fd), name)
+}
+
+func TestListenFDs_OtherPID(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	as, err := listenFDs()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if as.hasRole(sdRoleWhoson) {
+		t.Fatalf("sockets of other process should be ignored")
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatalf("LISTEN_FDS should be unset")
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify")
+	c, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer c.Close()
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", socket)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	b := make([]byte, 64)
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := c.Read(b)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if string(b[:n]) != "READY=1" {
+		t.Fatalf("got %q, want %q", b[:n], "READY=1")
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		usec string
+		pid  string
+		want time.Duration
+	}{
+		{"", "", 0},
+		{"2000000", "", time.Second},
+		{"2000000", strconv.Itoa(os.Getpid()), time.Second},
+		{"2000000", strconv.Itoa(os.Getpid() + 1), 0},
+		{"abc", "", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("WATCHDOG_USEC", tt.usec)
+		t.Setenv("WATCHDOG_PID", tt.pid)
+		if got := sdWatchdogInterval(); got != tt.want {
+			t.Fatalf("WATCHDOG_USEC=%q WATCHDOG_PID=%q: got %v, want %v", tt.usec, tt.pid, got, tt.want)
+		}
+	}
+}
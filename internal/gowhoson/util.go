@@ -1,11 +1,14 @@
 package gowhoson
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/tai-ga/gowhoson/pkg/whoson"
 	"github.com/urfave/cli/v3"
@@ -25,14 +28,33 @@ func GetServerConfig(c *cli.Command) (string, *whoson.ServerConfig, error) {
 		return "", nil, err
 	}
 	config := &whoson.ServerConfig{
-		TCP:        "127.0.0.1:9876",
-		UDP:        "127.0.0.1:9876",
-		Log:        "stdout",
-		Loglevel:   "error",
-		ServerID:   1000,
-		Expvar:     false,
-		SyncRemote: "",
-		SaveFile:   "",
+		TCP:              "127.0.0.1:9876",
+		UDP:              "127.0.0.1:9876",
+		Log:              "stdout",
+		Loglevel:         "error",
+		ServerID:         1000,
+		Expvar:           false,
+		SyncRemote:       "",
+		SaveFile:         "",
+		TTLDefault:       int(whoson.StoreDataExpire / time.Second),
+		TTLMin:           0,
+		TTLMax:           0,
+		SlidingExpire:    false,
+		SlidingInterval:  int(whoson.SlidingExpireInterval / time.Second),
+		MaxLifetime:      0,
+		AuthRequired:     false,
+		AuthWindow:       int(whoson.AuthWindow / time.Second),
+		HistoryFile:      "",
+		HistoryRetention: int(whoson.HistoryRetention / time.Second),
+		UnixPerm:         "0660",
+		TCPIdleTimeout:   int(whoson.SessionTimeOut / time.Second),
+		TCPReadTimeout:   int(whoson.SessionTimeOut / time.Second),
+		ShutdownTimeout:  int(whoson.ShutdownTimeout / time.Second),
+		HookWorkers:      whoson.HookWorkers,
+		HookTimeout:      int(whoson.HookTimeout / time.Second),
+		WebhookRetries:   whoson.WebhookRetries,
+		WebhookTimeout:   int(whoson.WebhookTimeout / time.Second),
+		MetricsPath:      whoson.MetricsPath,
 	}
 	if err == nil {
 		err = json.Unmarshal(b, &config)
@@ -50,6 +72,43 @@ func optOverwite(c *cli.Command, config *whoson.ClientConfig) {
 	if c.String("server") != "" {
 		config.Server = c.String("server")
 	}
+	if c.String("authkeyid") != "" {
+		config.AuthKeyID = c.String("authkeyid")
+	}
+	if c.String("authkey") != "" {
+		config.AuthKey = c.String("authkey")
+	}
+	if c.String("tlsca") != "" {
+		config.TLSCA = c.String("tlsca")
+	}
+	if c.String("tlscert") != "" {
+		config.TLSCert = c.String("tlscert")
+	}
+	if c.String("tlskey") != "" {
+		config.TLSKey = c.String("tlskey")
+	}
+}
+
+func dialClient(config *whoson.ClientConfig) (*whoson.Client, error) {
+	var client *whoson.Client
+	var err error
+	if strings.ToLower(config.Mode) == "tls" {
+		var tlsConfig *tls.Config
+		tlsConfig, err = whoson.NewClientTLSConfig(config.TLSCA, config.TLSCert, config.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		client, err = whoson.DialTLS(config.Server, tlsConfig)
+	} else {
+		client, err = whoson.Dial(config.Mode, config.Server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config.AuthKey != "" {
+		client.SetAuthKey(config.AuthKeyID, config.AuthKey)
+	}
+	return client, nil
 }
 
 func displayError(w io.Writer, e error) {
@@ -0,0 +1,107 @@
+package whoson
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ACLRule hold information for methods allowed from a network.
+type ACLRule struct {
+	Network string
+	Methods []string
+}
+
+type accessRule struct {
+	network *net.IPNet
+	methods map[MethodType]bool
+}
+
+// accessList is compiled ACLRule list, empty list allows everything.
+type accessList []accessRule
+
+func newAccessList(rules []ACLRule) (accessList, error) {
+	var al accessList
+	for _, rule := range rules {
+		network, err := parseNetwork(rule.Network)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ACL network %q", rule.Network)
+		}
+		methods, err := parseMethods(rule.Methods)
+		if err != nil {
+			return nil, errors.Wrap(err, "ACL")
+		}
+		ar := accessRule{
+			network: network,
+			methods: methods,
+		}
+		al = append(al, ar)
+	}
+	return al, nil
+}
+
+// parseMethods return set of method names, "ALL" means every method.
+func parseMethods(names []string) (map[MethodType]bool, error) {
+	methods := make(map[MethodType]bool)
+	for _, m := range names {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "ALL" {
+			for mt := range method {
+				methods[mt] = true
+			}
+			continue
+		}
+		mt, ok := methodFromString[m]
+		if !ok || mt == mUnkownMethod {
+			return nil, fmt.Errorf("method %q not found", m)
+		}
+		methods[mt] = true
+	}
+	return methods, nil
+}
+
+// parseNetwork return network of CIDR or single IP address.
+func parseNetwork(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.New("invalid IP address")
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	return network, err
+}
+
+// allowed return true when any rule matching ip allows method m.
+func (al accessList) allowed(ip net.IP, m MethodType) bool {
+	if len(al) == 0 || m == mQuit {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, ar := range al {
+		if ar.methods[m] && ar.network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkACL check the session remote address is allowed to use the method.
+func (ses *Session) checkACL() error {
+	if ses.isLocal() || getServerConfig().acl.allowed(ses.remoteIP(), ses.cmdMethod) {
+		return nil
+	}
+	expACLDeniedTotal.Add(1)
+	Log("warn", "checkACL:Denied", ses, nil)
+	return errors.New("access denied")
+}
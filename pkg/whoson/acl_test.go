@@ -0,0 +1,79 @@
+package whoson
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAccessList_allowed(t *testing.T) {
+	al, err := newAccessList([]ACLRule{
+		{Network: "10.0.1.0/24", Methods: []string{"LOGIN", "LOGOUT"}},
+		{Network: "10.0.2.1", Methods: []string{"query"}},
+		{Network: "2001:db8::/32", Methods: []string{"ALL"}},
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	var tests = []struct {
+		ip       string
+		method   MethodType
+		expected bool
+	}{
+		{"10.0.1.10", mLogin, true},
+		{"10.0.1.10", mLogout, true},
+		{"10.0.1.10", mQuery, false},
+		{"10.0.2.1", mQuery, true},
+		{"10.0.2.2", mQuery, false},
+		{"10.0.2.1", mLogin, false},
+		{"2001:db8::1", mRefresh, true},
+		{"192.168.0.1", mQuit, true},
+	}
+	for _, tt := range tests {
+		actual := al.allowed(net.ParseIP(tt.ip), tt.method)
+		if tt.expected != actual {
+			t.Fatalf("expected %v, actual %v, ip %v, method %v", tt.expected, actual, tt.ip, method[tt.method])
+		}
+	}
+
+	if !(accessList{}).allowed(net.ParseIP("192.168.0.1"), mLogin) {
+		t.Fatalf("expected empty ACL allows everything")
+	}
+}
+
+func TestNewAccessList_Error(t *testing.T) {
+	var tests = []ACLRule{
+		{Network: "10.0.1.0/33", Methods: []string{"LOGIN"}},
+		{Network: "example.org", Methods: []string{"LOGIN"}},
+		{Network: "10.0.1.0/24", Methods: []string{"DELETE"}},
+	}
+	for _, tt := range tests {
+		if _, err := newAccessList([]ACLRule{tt}); err == nil {
+			t.Fatalf("expected error, rule %+v", tt)
+		}
+	}
+}
+
+func TestSession_checkACL(t *testing.T) {
+	NewLogger("discard", "error")
+	err := SetServerConfig(&ServerConfig{
+		ACL: []ACLRule{{Network: "10.0.1.0/24", Methods: []string{"QUERY"}}},
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer SetServerConfig(nil)
+
+	ses := &Session{
+		protocol:   pUDP,
+		remoteAddr: &net.UDPAddr{IP: net.ParseIP("10.0.1.1"), Port: 10000},
+		cmdMethod:  mQuery,
+	}
+	if err := ses.checkACL(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	ses.cmdMethod = mLogin
+	if err := ses.checkACL(); err == nil {
+		t.Fatalf("expected access denied")
+	}
+}
@@ -0,0 +1,310 @@
+package whoson
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	apiDefaultLimit = 100
+	apiMaxLimit     = 1000
+	apiMaxBodySize  = 64 << 10
+)
+
+var (
+	errAPINotFound = errors.New("no such record")
+	errAPIBadData  = errors.New("data must not contain line breaks")
+
+	errAPIRateLimited = errors.New("rate limit exceeded")
+	errAPIDenied      = errors.New("access denied")
+	errAPIAuth        = errors.New("authentication failed")
+)
+
+// APIRecord is store data in HTTP API responses.
+type APIRecord struct {
+	IP      string
+	Data    string
+	Expire  time.Time
+	TTL     int
+	Created time.Time
+}
+
+// APIRecords is a page of records sorted by IP.
+type APIRecords struct {
+	Total   int
+	Offset  int
+	Limit   int
+	Records []*APIRecord
+}
+
+// APILoginRequest is body of POST /v1/login, TTL is seconds and default when zero.
+type APILoginRequest struct {
+	IP   string
+	Data string
+	TTL  int
+}
+
+// APILogoutRequest is body of POST /v1/logout.
+type APILogoutRequest struct {
+	IP string
+}
+
+// APIError is body of HTTP API error responses.
+type APIError struct {
+	Error string
+}
+
+// apiFunc handle a request, and return status and value of response body.
+type apiFunc func(r *http.Request) (int, interface{}, error)
+
+func newAPIRecord(sd *StoreData) *APIRecord {
+	return &APIRecord{
+		IP:      sd.Key(),
+		Data:    sd.Data,
+		Expire:  sd.Expire,
+		TTL:     int(sd.TTL / time.Second),
+		Created: sd.Created,
+	}
+}
+
+// NewAPIHandler return http.Handler of HTTP API backed by MainStore.
+func NewAPIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/login", apiHandler(http.MethodPost, mLogin, apiLogin))
+	mux.HandleFunc("/v1/logout", apiHandler(http.MethodPost, mLogout, apiLogout))
+	mux.HandleFunc("/v1/query", apiHandler(http.MethodGet, mQuery, apiQuery))
+	mux.HandleFunc("/v1/records", apiHandler(http.MethodGet, mLookup, apiRecords))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIResponse(w, http.StatusNotFound, nil, errors.New("not found"))
+	})
+	return mux
+}
+
+func apiHandler(httpMethod string, m MethodType, f apiFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, result := time.Now(), resultAllowed
+		defer func() {
+			observeRequest(protocolHTTP, m, start, result)
+		}()
+		if r.Method != httpMethod {
+			w.Header().Set("Allow", httpMethod)
+			writeAPIResponse(w, http.StatusMethodNotAllowed, nil, errors.New("method not allowed"))
+			return
+		}
+		if status, err := apiAuthorize(w, r, m); err != nil {
+			result = resultDenied
+			writeAPIResponse(w, status, nil, err)
+			return
+		}
+		status, v, err := f(r)
+		Log("debug", fmt.Sprintf("APIHandler:%s %s %s %d", r.RemoteAddr, r.Method, r.URL.Path, status), nil, err)
+		writeAPIResponse(w, status, v, err)
+	}
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, v interface{}, err error) {
+	if err != nil {
+		v = &APIError{Error: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiAuthorize check request of HTTP API.
+func apiAuthorize(w http.ResponseWriter, r *http.Request, m MethodType) (int, error) {
+	switch err := authorizeRequest(apiRemoteIP(r), r.RemoteAddr, m, r.Header.Get("Authorization")); err {
+	case nil:
+		return http.StatusOK, nil
+	case errAPIRateLimited:
+		return http.StatusTooManyRequests, err
+	case errAPIDenied:
+		return http.StatusForbidden, err
+	default:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		return http.StatusUnauthorized, err
+	}
+}
+
+// authorizeRequest check rate limit, ACL and API token of HTTP API and gRPC
+// whoson service like whoson sessions, remote is client address for logging.
+func authorizeRequest(ip net.IP, remote string, m MethodType, authorization string) error {
+	config := getServerConfig()
+	if !config.limiter.allow(ip, m, time.Now()) {
+		expRateLimitedTotal.Add(1)
+		Log("debug", fmt.Sprintf("authorizeRequest:Limited %s", remote), nil, nil)
+		return errAPIRateLimited
+	}
+	if !config.acl.allowed(ip, m) {
+		expACLDeniedTotal.Add(1)
+		Log("warn", fmt.Sprintf("authorizeRequest:Denied %s", remote), nil, nil)
+		return errAPIDenied
+	}
+	if !config.apiAuthenticate(m, authorization) {
+		expAuthFailuresTotal.Add(1)
+		Log("warn", fmt.Sprintf("authorizeRequest:Unauthorized %s", remote), nil, nil)
+		return errAPIAuth
+	}
+	return nil
+}
+
+// apiAuthenticate check "Bearer <token>" of APITokens. Without APITokens, every request
+// is allowed, except methods needing signature of whoson commands when AuthRequired.
+func (c *ServerConfig) apiAuthenticate(m MethodType, authorization string) bool {
+	if len(c.APITokens) == 0 {
+		return !c.AuthRequired || !signedMethod(m)
+	}
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	for _, t := range c.APITokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func apiRemoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+func decodeAPIRequest(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, apiMaxBodySize)).Decode(v); err != nil {
+		return errors.Wrap(err, "request parse error")
+	}
+	return nil
+}
+
+func apiLogin(r *http.Request) (int, interface{}, error) {
+	req := &APILoginRequest{}
+	if err := decodeAPIRequest(r, req); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	sd, err := parseKey(req.IP)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "IP %q", req.IP)
+	}
+	if req.TTL < 0 {
+		return http.StatusBadRequest, nil, errors.New("TTL must not be negative")
+	}
+	if strings.ContainsAny(req.Data, "\r\n") {
+		return http.StatusBadRequest, nil, errAPIBadData
+	}
+	expCommandLoginTotal.Add(1)
+	sd.Data = req.Data
+	loginData(r.Context(), sd, time.Duration(req.TTL)*time.Second)
+	return http.StatusOK, newAPIRecord(sd), nil
+}
+
+func apiLogout(r *http.Request) (int, interface{}, error) {
+	req := &APILogoutRequest{}
+	if err := decodeAPIRequest(r, req); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	sd, err := parseKey(req.IP)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "IP %q", req.IP)
+	}
+	expCommandLogoutTotal.Add(1)
+	deleted, ok := logoutData(r.Context(), sd.Key())
+	if !ok {
+		return http.StatusNotFound, nil, errAPINotFound
+	}
+	if deleted == nil {
+		deleted = sd
+	}
+	return http.StatusOK, newAPIRecord(deleted), nil
+}
+
+// parseQueryKey parse IP address or IP address with single port like QUERY.
+func parseQueryKey(s string) (*StoreData, error) {
+	key, err := parseKey(s)
+	if err != nil || key.PrefixLen > 0 || key.PortFrom != key.PortTo {
+		return nil, errors.Errorf("IP %q parse error", s)
+	}
+	return key, nil
+}
+
+// apiQuery find record of "ip" parameter, which is IP address or IP address with single port.
+func apiQuery(r *http.Request) (int, interface{}, error) {
+	key, err := parseQueryKey(r.URL.Query().Get("ip"))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	expCommandQueryTotal.Add(1)
+	sd, err := queryData(r.Context(), key.IP, key.PortFrom)
+	if err != nil {
+		return http.StatusNotFound, nil, errAPINotFound
+	}
+	return http.StatusOK, newAPIRecord(sd), nil
+}
+
+// apiRecords list records of "user" parameter or all records, from "offset" up to "limit".
+func apiRecords(r *http.Request) (int, interface{}, error) {
+	q := r.URL.Query()
+	offset, err := apiIntParam(q.Get("offset"), 0)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "offset")
+	}
+	limit, err := apiIntParam(q.Get("limit"), apiDefaultLimit)
+	if err != nil || limit == 0 || limit > apiMaxLimit {
+		return http.StatusBadRequest, nil, errors.Errorf("limit must be 1 to %d", apiMaxLimit)
+	}
+
+	total, sds := listData(q.Get("user"), offset, limit)
+	page := &APIRecords{Total: total, Offset: offset, Limit: limit, Records: []*APIRecord{}}
+	for _, sd := range sds {
+		page.Records = append(page.Records, newAPIRecord(sd))
+	}
+	return http.StatusOK, page, nil
+}
+
+// listData return number of data of user or all data, and data from offset up to limit sorted by key.
+// Expired data waiting for RunExpireChecker is not listed.
+func listData(user string, offset, limit int) (int, []*StoreData) {
+	var sds []*StoreData
+	if user != "" {
+		expCommandLookupTotal.Add(1)
+		sds = storeFindByUser(MainStore, user)
+	} else {
+		now := time.Now()
+		for _, sd := range MainStore.Items() {
+			if sd.Expire.After(now) {
+				sds = append(sds, sd)
+			}
+		}
+	}
+	sort.Slice(sds, func(i, j int) bool {
+		return sds[i].Key() < sds[j].Key()
+	})
+	if offset >= len(sds) {
+		return len(sds), nil
+	}
+	return len(sds), sds[offset:min(offset+limit, len(sds))]
+}
+
+func apiIntParam(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("%q must be non-negative integer", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,207 @@
+package whoson
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func apiRequest(t *testing.T, ts *httptest.Server, method, path, token, body string, v interface{}) int {
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("%s %s: unexpected Content-Type %q", method, path, ct)
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: Error %v", method, path, err)
+	}
+	return res.StatusCode
+}
+
+func TestAPIHandler(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{TTLMax: 600})
+	ts := httptest.NewServer(NewAPIHandler())
+	defer ts.Close()
+
+	rec := &APIRecord{}
+	status := apiRequest(t, ts, "POST", "/v1/login", "", `{"IP": "198.18.0.1", "Data": "apiuser1", "TTL": 3600}`, rec)
+	if status != http.StatusOK || rec.IP != "198.18.0.1" || rec.Data != "apiuser1" || rec.TTL != 600 {
+		t.Fatalf("login: unexpected %d %+v", status, rec)
+	}
+	rec = &APIRecord{}
+	status = apiRequest(t, ts, "GET", "/v1/query?ip=198.18.0.1", "", "", rec)
+	if status != http.StatusOK || rec.Data != "apiuser1" {
+		t.Fatalf("query: unexpected %d %+v", status, rec)
+	}
+	if sd, err := MainStore.Get("198.18.0.1"); err != nil || sd.Data != "apiuser1" {
+		t.Fatalf("login should be stored to MainStore, %v %v", sd, err)
+	}
+
+	var tests = []struct {
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"POST", "/v1/login", `{"IP": "198.18.0.999", "Data": "apiuser1"}`, http.StatusBadRequest},
+		{"POST", "/v1/login", `{"IP": "198.18.0.2", "Data": "apiuser1\r\n+"}`, http.StatusBadRequest},
+		{"POST", "/v1/login", `{"IP": "198.18.0.2", "TTL": -1}`, http.StatusBadRequest},
+		{"POST", "/v1/login", `{"IP": `, http.StatusBadRequest},
+		{"GET", "/v1/login", "", http.StatusMethodNotAllowed},
+		{"GET", "/v1/query?ip=198.18.0.0/24", "", http.StatusBadRequest},
+		{"GET", "/v1/query?ip=198.18.0.3", "", http.StatusNotFound},
+		{"POST", "/v1/logout", `{"IP": "198.18.0.3"}`, http.StatusNotFound},
+		{"GET", "/v1/records?limit=0", "", http.StatusBadRequest},
+		{"GET", "/v1/records?offset=-1", "", http.StatusBadRequest},
+		{"GET", "/v2/records", "", http.StatusNotFound},
+		{"POST", "/v1/logout", `{"IP": "198.18.0.1"}`, http.StatusOK},
+		{"GET", "/v1/query?ip=198.18.0.1", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		v := map[string]interface{}{}
+		status := apiRequest(t, ts, tt.method, tt.path, "", tt.body, &v)
+		if status != tt.expected {
+			t.Fatalf("%s %s %s: expected %d, actual %d %v", tt.method, tt.path, tt.body, tt.expected, status, v)
+		}
+		if status != http.StatusOK && v["Error"] == nil {
+			t.Fatalf("%s %s: error body expected, actual %v", tt.method, tt.path, v)
+		}
+	}
+}
+
+func TestAPIHandler_Records(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{})
+	ts := httptest.NewServer(NewAPIHandler())
+	defer ts.Close()
+
+	for _, ip := range []string{"198.18.1.3", "198.18.1.1", "198.18.1.0/30", "198.18.1.2:1024-2047"} {
+		status := apiRequest(t, ts, "POST", "/v1/login", "", `{"IP": "`+ip+`", "Data": "apiuser2"}`, &APIRecord{})
+		if status != http.StatusOK {
+			t.Fatalf("login %s: unexpected %d", ip, status)
+		}
+	}
+	var tests = []struct {
+		path     string
+		expected []string
+	}{
+		{"/v1/records?user=apiuser2", []string{"198.18.1.0/30", "198.18.1.1", "198.18.1.2:1024-2047", "198.18.1.3"}},
+		{"/v1/records?user=apiuser2&limit=2", []string{"198.18.1.0/30", "198.18.1.1"}},
+		{"/v1/records?user=apiuser2&offset=2&limit=1", []string{"198.18.1.2:1024-2047"}},
+		{"/v1/records?user=apiuser2&offset=4", []string{}},
+	}
+	for _, tt := range tests {
+		page := &APIRecords{}
+		if status := apiRequest(t, ts, "GET", tt.path, "", "", page); status != http.StatusOK {
+			t.Fatalf("%s: unexpected %d", tt.path, status)
+		}
+		if page.Total != 4 || len(page.Records) != len(tt.expected) {
+			t.Fatalf("%s: unexpected %+v", tt.path, page)
+		}
+		for i, r := range page.Records {
+			if r.IP != tt.expected[i] {
+				t.Fatalf("%s: expected %v, actual %v", tt.path, tt.expected[i], r.IP)
+			}
+		}
+	}
+	for _, ip := range []string{"198.18.1.3", "198.18.1.1", "198.18.1.0/30", "198.18.1.2:1024-2047"} {
+		logoutData(context.Background(), ip)
+	}
+}
+
+func TestListData_Expired(t *testing.T) {
+	NewMainStore()
+	before, _ := listData("", 0, apiMaxLimit)
+	MainStore.SyncSet("198.18.1.9", &StoreData{IP: net.ParseIP("198.18.1.9"), Data: "apiuser3", Expire: time.Now().Add(-time.Second)})
+	defer MainStore.SyncDel("198.18.1.9")
+	if total, sds := listData("", 0, apiMaxLimit); total != before || len(sds) != before {
+		t.Fatalf("expired data should not be listed, expected %d, actual %d %v", before, total, sds)
+	}
+	if total, _ := listData("apiuser3", 0, apiMaxLimit); total != 0 {
+		t.Fatalf("expired data of user should not be listed, actual %d", total)
+	}
+}
+
+func TestAPIHandler_Authorize(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	ts := httptest.NewServer(NewAPIHandler())
+	defer ts.Close()
+
+	var tests = []struct {
+		config   *ServerConfig
+		token    string
+		expected int
+	}{
+		{&ServerConfig{APITokens: map[string]string{"portal": "t0ken"}}, "t0ken", http.StatusNotFound},
+		{&ServerConfig{APITokens: map[string]string{"portal": "t0ken"}}, "", http.StatusUnauthorized},
+		{&ServerConfig{APITokens: map[string]string{"portal": "t0ken"}}, "wrong", http.StatusUnauthorized},
+		{&ServerConfig{ACL: []ACLRule{{Network: "192.0.2.0/24", Methods: []string{"ALL"}}}}, "", http.StatusForbidden},
+		{&ServerConfig{ACL: []ACLRule{{Network: "127.0.0.1", Methods: []string{"QUERY"}}}}, "", http.StatusNotFound},
+		{&ServerConfig{RateLimits: []RateLimit{{Methods: []string{"QUERY"}, Rate: 1, Burst: 1}}}, "", http.StatusTooManyRequests},
+	}
+	for i, tt := range tests {
+		if err := SetServerConfig(tt.config); err != nil {
+			t.Fatalf("%d: Error %v", i, err)
+		}
+		var status int
+		for n := 0; n < 2; n++ {
+			status = apiRequest(t, ts, "GET", "/v1/query?ip=198.18.2.1", tt.token, "", &APIError{})
+		}
+		if status != tt.expected {
+			t.Fatalf("%d: expected %d, actual %d", i, tt.expected, status)
+		}
+	}
+}
+
+func TestAPIHandler_AuthRequired(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	ts := httptest.NewServer(NewAPIHandler())
+	defer ts.Close()
+
+	var tests = []struct {
+		config   *ServerConfig
+		token    string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{&ServerConfig{AuthRequired: true}, "", "POST", "/v1/login", `{"IP": "198.18.2.2"}`, http.StatusUnauthorized},
+		{&ServerConfig{AuthRequired: true}, "", "POST", "/v1/logout", `{"IP": "198.18.2.2"}`, http.StatusUnauthorized},
+		{&ServerConfig{AuthRequired: true}, "", "GET", "/v1/query?ip=198.18.2.2", "", http.StatusNotFound},
+		{&ServerConfig{AuthRequired: true, APITokens: map[string]string{"portal": "t0ken"}}, "t0ken", "POST", "/v1/login", `{"IP": "198.18.2.2"}`, http.StatusOK},
+		{&ServerConfig{AuthRequired: true, APITokens: map[string]string{"portal": "t0ken"}}, "t0ken", "POST", "/v1/logout", `{"IP": "198.18.2.2"}`, http.StatusOK},
+	}
+	for i, tt := range tests {
+		if err := SetServerConfig(tt.config); err != nil {
+			t.Fatalf("%d: Error %v", i, err)
+		}
+		if status := apiRequest(t, ts, tt.method, tt.path, tt.token, tt.body, &map[string]interface{}{}); status != tt.expected {
+			t.Fatalf("%d: expected %d, actual %d", i, tt.expected, status)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package whoson
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var authNonces = newNonceCache()
+
+// authParams hold information for signed command.
+type authParams struct {
+	timestamp int64
+	nonce     string
+	keyID     string
+	signature string
+	// signed is canonical command line covered by signature.
+	signed string
+}
+
+func (a *authParams) set(token string) bool {
+	lower := strings.ToLower(token)
+	switch {
+	case strings.HasPrefix(lower, authTimestampPrefix) && a.timestamp == 0:
+		ts, err := strconv.ParseInt(token[len(authTimestampPrefix):], 10, 64)
+		if err != nil || ts <= 0 {
+			return false
+		}
+		a.timestamp = ts
+	case strings.HasPrefix(lower, authNoncePrefix) && a.nonce == "":
+		a.nonce = token[len(authNoncePrefix):]
+	case strings.HasPrefix(lower, authKeyIDPrefix) && a.keyID == "":
+		a.keyID = token[len(authKeyIDPrefix):]
+	default:
+		return false
+	}
+	return true
+}
+
+// verify check signature, timestamp and nonce of signed command.
+func (a *authParams) verify(config *ServerConfig, now time.Time) error {
+	if a.timestamp == 0 || a.nonce == "" || a.keyID == "" {
+		return errors.New("signature parameter missing")
+	}
+	key, ok := config.AuthKeys[a.keyID]
+	if !ok {
+		return fmt.Errorf("unknown key id %q", a.keyID)
+	}
+	if !hmac.Equal([]byte(a.signature), []byte(authSign(key, a.signed))) {
+		return errors.New("signature mismatch")
+	}
+
+	window := AuthWindow
+	if config.AuthWindow > 0 {
+		window = time.Duration(config.AuthWindow) * time.Second
+	}
+	ts := time.Unix(a.timestamp, 0)
+	if ts.Before(now.Add(-window)) || ts.After(now.Add(window)) {
+		return errors.New("timestamp out of window")
+	}
+	if !authNonces.add(a.keyID+":"+a.nonce, now, ts.Add(window)) {
+		return errors.New("nonce replayed")
+	}
+	return nil
+}
+
+// signedMethod return true for methods changing the store, which are signed.
+func signedMethod(m MethodType) bool {
+	switch m {
+	case mLogin, mLogout, mRefresh:
+		return true
+	}
+	return false
+}
+
+// authEnabled return true when signed commands are verified by AuthKeys or required.
+func (c *ServerConfig) authEnabled() bool {
+	return len(c.AuthKeys) > 0 || c.AuthRequired
+}
+
+// authenticate verify signature of LOGIN, LOGOUT and REFRESH.
+func (ses *Session) authenticate() error {
+	if !signedMethod(ses.cmdMethod) {
+		return nil
+	}
+
+	config := getServerConfig()
+	if ses.cmdAuth == nil {
+		if config.AuthRequired {
+			expAuthFailuresTotal.Add(1)
+			Log("warn", "authenticate:Failed", ses, errors.New("signature required"))
+			return errors.New("authentication required")
+		}
+		return nil
+	}
+	if err := ses.cmdAuth.verify(config, time.Now()); err != nil {
+		expAuthFailuresTotal.Add(1)
+		Log("warn", "authenticate:Failed", ses, err)
+		return errors.New("authentication failed")
+	}
+	return nil
+}
+
+func authSign(key, line string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(line))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func authCanonical(cmd []string) string {
+	c := make([]string, len(cmd))
+	copy(c, cmd)
+	c[0] = strings.ToUpper(c[0])
+	return strings.Join(c, " ")
+}
+
+// authSignLine return line with timestamp, nonce, key id and signature.
+func authSignLine(line, keyID, key string, now time.Time) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	cmd := splitCmd(line)
+	cmd = append(cmd,
+		authTimestampPrefix+strconv.FormatInt(now.Unix(), 10),
+		authNoncePrefix+hex.EncodeToString(b),
+		authKeyIDPrefix+keyID,
+	)
+	signed := authCanonical(cmd)
+	return signed + " " + authSignaturePrefix + authSign(key, signed), nil
+}
+
+// nonceCache hold nonces seen until their timestamp leaves window.
+type nonceCache struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastPurge time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// add return false when nonce is already seen.
+func (nc *nonceCache) add(nonce string, now, expire time.Time) bool {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	if now.Sub(nc.lastPurge) > time.Second {
+		for k, v := range nc.nonces {
+			if v.Before(now) {
+				delete(nc.nonces, k)
+			}
+		}
+		nc.lastPurge = now
+	}
+	if v, ok := nc.nonces[nonce]; ok && !v.Before(now) {
+		return false
+	}
+	nc.nonces[nonce] = expire
+	return true
+}
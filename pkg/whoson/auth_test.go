@@ -0,0 +1,83 @@
+package whoson
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSession_authenticate(t *testing.T) {
+	NewLogger("discard", "error")
+	SetServerConfig(&ServerConfig{
+		AuthKeys:     map[string]string{"pop01": "secret01"},
+		AuthRequired: true,
+	})
+	defer SetServerConfig(nil)
+
+	now := time.Now()
+	signed, err := authSignLine("LOGIN 10.0.0.1 user01 ttl=60", "pop01", "secret01", now)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	badKey, _ := authSignLine("LOGIN 10.0.0.1 user01", "pop01", "secret02", now)
+	unknownKey, _ := authSignLine("LOGIN 10.0.0.1 user01", "pop02", "secret01", now)
+	oldTime, _ := authSignLine("LOGIN 10.0.0.1 user01", "pop01", "secret01", now.Add(-time.Hour))
+	tampered := strings.Replace(signed, "user01", "user02", 1)
+
+	var tests = []struct {
+		line     string
+		hasError bool
+	}{
+		{signed, false},
+		{signed, true},
+		{badKey, true},
+		{unknownKey, true},
+		{oldTime, true},
+		{tampered, true},
+		{"LOGIN 10.0.0.1 user01", true},
+		{"QUERY 10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		ses := &Session{}
+		if err := ses.parseCmd(tt.line); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		err := ses.authenticate()
+		if (err != nil) != tt.hasError {
+			t.Fatalf("expected error %v, actual %v, line %q", tt.hasError, err, tt.line)
+		}
+	}
+}
+
+func TestSession_parseCmdSigned(t *testing.T) {
+	SetServerConfig(&ServerConfig{AuthKeys: map[string]string{"pop01": "secret01"}})
+	defer SetServerConfig(nil)
+	line, err := authSignLine("LOGIN 10.0.0.1 user01 ttl=60", "pop01", "secret01", time.Now())
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	ses := &Session{}
+	if err := ses.parseCmd(line); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if ses.cmdArgs != "user01" || ses.cmdTTL != time.Minute {
+		t.Fatalf("expected %q %v, actual %q %v", "user01", time.Minute, ses.cmdArgs, ses.cmdTTL)
+	}
+	if ses.cmdAuth == nil || ses.cmdAuth.keyID != "pop01" {
+		t.Fatalf("expected key id pop01, actual %+v", ses.cmdAuth)
+	}
+}
+
+func TestNonceCache_add(t *testing.T) {
+	nc := newNonceCache()
+	now := time.Now()
+	if !nc.add("n1", now, now.Add(time.Minute)) {
+		t.Fatalf("expected first nonce accepted")
+	}
+	if nc.add("n1", now, now.Add(time.Minute)) {
+		t.Fatalf("expected replayed nonce rejected")
+	}
+	if !nc.add("n1", now.Add(2*time.Minute), now.Add(3*time.Minute)) {
+		t.Fatalf("expected expired nonce accepted")
+	}
+}
@@ -1,10 +1,14 @@
 package whoson
 
 import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/textproto"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -40,15 +44,45 @@ type Client struct {
 	tp         *textproto.Conn
 	conn       net.Conn
 	serverName string
+	authKeyID  string
+	authKey    string
 }
 
-// Dial creates a new client connection.
+// Dial creates a new client connection, proto "tls" uses system roots,
+// and addr of "unix" and "unixgram" is socket path.
 func Dial(proto string, addr string) (*Client, error) {
-	proto = strings.ToLower(proto)
-	if proto != "tcp" && proto != "udp" {
+	var conn net.Conn
+	var err error
+	switch strings.ToLower(proto) {
+	case "tls":
+		return DialTLS(addr, &tls.Config{MinVersion: tls.VersionTLS12})
+	case "tcp", "udp", "unix":
+		conn, err = net.Dial(strings.ToLower(proto), addr)
+	case "unixgram":
+		conn, err = dialUnixgram(addr)
+	default:
 		return nil, errors.New("Unknown protocol error")
 	}
-	conn, err := net.Dial(proto, addr)
+	if err != nil {
+		return nil, err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	return NewClient(conn, host)
+}
+
+// dialUnixgram bind client socket to unique abstract address, to receive response.
+func dialUnixgram(path string) (net.Conn, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	laddr := &net.UnixAddr{Name: "@gowhoson-" + hex.EncodeToString(b), Net: "unixgram"}
+	return net.DialUnix("unixgram", laddr, &net.UnixAddr{Name: path, Net: "unixgram"})
+}
+
+// DialTLS creates a new client TLS connection.
+func DialTLS(addr string, config *tls.Config) (*Client, error) {
+	conn, err := tls.Dial("tcp", addr, config)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +97,12 @@ func NewClient(conn net.Conn, host string) (*Client, error) {
 	return c, nil
 }
 
+// SetAuthKey set shared secret key, LOGIN, LOGOUT and REFRESH are signed when key is set.
+func (c *Client) SetAuthKey(keyID string, key string) {
+	c.authKeyID = keyID
+	c.authKey = key
+}
+
 // Close closes the connection.
 func (c *Client) Close() error {
 	return c.tp.Close()
@@ -70,7 +110,19 @@ func (c *Client) Close() error {
 
 // Login access to LOGIN API.
 func (c *Client) Login(ip string, args string) (*Response, error) {
-	resp, err := c.doAPI("LOGIN %s %s", ip, args)
+	resp, err := c.doSignedAPI("LOGIN %s %s", ip, args)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// LoginWithTTL access to LOGIN API with ttl, server default is used if ttl is zero.
+func (c *Client) LoginWithTTL(ip string, args string, ttl time.Duration) (*Response, error) {
+	if ttl <= 0 {
+		return c.Login(ip, args)
+	}
+	resp, err := c.doSignedAPI("LOGIN %s %s %s%d", ip, args, ttlArgPrefix, int64(ttl/time.Second))
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +131,7 @@ func (c *Client) Login(ip string, args string) (*Response, error) {
 
 // Logout access to LOGOUT API.
 func (c *Client) Logout(ip string) (*Response, error) {
-	resp, err := c.doAPI("LOGOUT %s", ip)
+	resp, err := c.doSignedAPI("LOGOUT %s", ip)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +147,36 @@ func (c *Client) Query(ip string) (*Response, error) {
 	return resp, nil
 }
 
+// Refresh access to REFRESH API.
+func (c *Client) Refresh(ip string) (*Response, error) {
+	resp, err := c.doSignedAPI("REFRESH %s", ip)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RefreshWithTTL access to REFRESH API with ttl, record ttl is used if ttl is zero.
+func (c *Client) RefreshWithTTL(ip string, ttl time.Duration) (*Response, error) {
+	if ttl <= 0 {
+		return c.Refresh(ip)
+	}
+	resp, err := c.doSignedAPI("REFRESH %s %s%d", ip, ttlArgPrefix, int64(ttl/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Lookup access to LOOKUP API, response has IP addresses logged in by user.
+func (c *Client) Lookup(user string) (*Response, error) {
+	resp, err := c.doAPI("LOOKUP %s", user)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // Quit access to QUIT API.
 func (c *Client) Quit() (*Response, error) {
 	resp, err := c.doAPI("QUIT")
@@ -104,6 +186,17 @@ func (c *Client) Quit() (*Response, error) {
 	return resp, nil
 }
 
+func (c *Client) doSignedAPI(format string, args ...interface{}) (*Response, error) {
+	if c.authKey == "" {
+		return c.doAPI(format, args...)
+	}
+	line, err := authSignLine(fmt.Sprintf(format, args...), c.authKeyID, c.authKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return c.doAPI("%s", line)
+}
+
 func (c *Client) doAPI(format string, args ...interface{}) (*Response, error) {
 	id, err := c.tp.Cmd(fmt.Sprintf("%s%s", format, charCRLF), args...)
 	if err != nil {
@@ -97,17 +97,35 @@ func TestClient_Commands(t *testing.T) {
 		{"udp", "login", "1.1.1.1", "TESTSTRING", "+LOGIN OK"},
 		{"udp", "query", "1.1.1.1", "", "+TESTSTRING"},
 		{"udp", "query", "1.1.1.2", "", "-Not Logged in"},
+		{"udp", "refresh", "1.1.1.1", "", "+REFRESH OK"},
 		{"udp", "logout", "1.1.1.1", "", "+LOGOUT record deleted"},
 		{"udp", "logout", "1.1.1.1", "", "+LOGOUT no such record, nothing done"},
+		{"udp", "refresh", "1.1.1.1", "", "-REFRESH no such record"},
 		{"tcp", "login", "1.1.1", "TESTSTRING", "*command parse error"},
 		{"tcp", "login", "1.1.1.1", "TESTSTRING", "+LOGIN OK"},
 		{"tcp", "query", "1.1.1.1", "", "+TESTSTRING"},
 		{"tcp", "query", "1.1.1.2", "", "-Not Logged in"},
+		{"tcp", "refresh", "1.1.1.1", "", "+REFRESH OK"},
 		{"tcp", "logout", "1.1.1.1", "", "+LOGOUT record deleted"},
 		{"tcp", "logout", "1.1.1.1", "", "+LOGOUT no such record, nothing done"},
+		{"tcp", "refresh", "1.1.1.1", "", "-REFRESH no such record"},
 		{"udp", "login", "2.2.2.2", "TESTSTRING2", "+LOGIN OK"},
 		{"tcp", "query", "2.2.2.2", "", "+TESTSTRING2"},
 		{"udp", "logout", "2.2.2.2", "", "+LOGOUT record deleted"},
+		{"udp", "login", "3.3.0.0/16", "TESTPREFIX", "+LOGIN OK"},
+		{"tcp", "query", "3.3.3.3", "", "+TESTPREFIX"},
+		{"tcp", "query", "3.3.0.0/16", "", "*command parse error"},
+		{"tcp", "logout", "3.3.0.0/16", "", "+LOGOUT record deleted"},
+		{"udp", "query", "3.3.3.3", "", "-Not Logged in"},
+		{"udp", "login", "4.4.4.4:1024-2047", "TESTPORT1", "+LOGIN OK"},
+		{"tcp", "login", "4.4.4.4:2048-3071", "TESTPORT2", "+LOGIN OK"},
+		{"udp", "query", "4.4.4.4:1500", "", "+TESTPORT1"},
+		{"tcp", "query", "4.4.4.4:2500", "", "+TESTPORT2"},
+		{"tcp", "query", "4.4.4.4", "", "-Not Logged in"},
+		{"tcp", "query", "4.4.4.4:1024-2047", "", "*command parse error"},
+		{"udp", "logout", "4.4.4.4:1024-2047", "", "+LOGOUT record deleted"},
+		{"udp", "query", "4.4.4.4:1500", "", "-Not Logged in"},
+		{"tcp", "logout", "4.4.4.4:2048-3071", "", "+LOGOUT record deleted"},
 		{"udp", "quit", "", "", "+QUIT OK"},
 		{"tcp", "quit", "", "", "+QUIT OK"},
 	}
@@ -131,6 +149,12 @@ func TestClient_Commands(t *testing.T) {
 			} else {
 				r, err = te.tcpclient.Query(tt.args1)
 			}
+		case "refresh":
+			if tt.protocol == "udp" {
+				r, err = te.udpclient.Refresh(tt.args1)
+			} else {
+				r, err = te.tcpclient.Refresh(tt.args1)
+			}
 		case "quit":
 			if tt.protocol == "udp" {
 				r, err = te.udpclient.Quit()
@@ -0,0 +1,127 @@
+package whoson
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var serverConfig atomic.Pointer[ServerConfig]
+
+// SetServerConfig set ServerConfig used by whoson sessions.
+func SetServerConfig(config *ServerConfig) error {
+	if config == nil {
+		serverConfig.Store(nil)
+		return nil
+	}
+	acl, err := newAccessList(config.ACL)
+	if err != nil {
+		return err
+	}
+	limiter, err := newRateLimiter(config.RateLimits, config.RateLimitMaxEntries)
+	if err != nil {
+		return err
+	}
+	proxies, err := newTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	hooks, err := newHookList(config.Hooks)
+	if err != nil {
+		return err
+	}
+	webhooks, err := newWebhookList(config.Webhooks)
+	if err != nil {
+		return err
+	}
+	c := *config
+	c.acl = acl
+	c.limiter = limiter
+	c.proxies = proxies
+	c.hooks = hooks
+	c.webhooks = webhooks
+	c.syncHosts = splitHosts(config.SyncRemote)
+	serverConfig.Store(&c)
+	return nil
+}
+
+func splitHosts(hosts string) []string {
+	var hs []string
+	for _, h := range strings.Split(hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
+
+func getServerConfig() *ServerConfig {
+	if config := serverConfig.Load(); config != nil {
+		return config
+	}
+	return &ServerConfig{}
+}
+
+// LoginTTL return ttl for LOGIN, requested is zero when client did not specify ttl.
+func (c *ServerConfig) LoginTTL(requested time.Duration) time.Duration {
+	ttl := StoreDataExpire
+	if c.TTLDefault > 0 {
+		ttl = time.Duration(c.TTLDefault) * time.Second
+	}
+	if requested > 0 {
+		ttl = requested
+	}
+	if c.TTLMin > 0 && ttl < time.Duration(c.TTLMin)*time.Second {
+		ttl = time.Duration(c.TTLMin) * time.Second
+	}
+	if c.TTLMax > 0 && ttl > time.Duration(c.TTLMax)*time.Second {
+		ttl = time.Duration(c.TTLMax) * time.Second
+	}
+	return ttl
+}
+
+// ttlEnabled return true when LOGIN ttl is configured by TTLDefault, TTLMin or TTLMax.
+func (c *ServerConfig) ttlEnabled() bool {
+	return c.TTLDefault > 0 || c.TTLMin > 0 || c.TTLMax > 0
+}
+
+// SlideExpire return new expire time of sd extended by QUERY.
+// ok is false when the extension is shorter than SlidingInterval, so that
+// frequent queries do not update and sync the record every time.
+func (c *ServerConfig) SlideExpire(sd *StoreData, now time.Time) (expire time.Time, ok bool) {
+	ttl := sd.TTL
+	if ttl <= 0 {
+		ttl = c.LoginTTL(0)
+	}
+	expire = now.Add(ttl)
+	if c.MaxLifetime > 0 && !sd.Created.IsZero() {
+		if limit := sd.Created.Add(time.Duration(c.MaxLifetime) * time.Second); expire.After(limit) {
+			expire = limit
+		}
+	}
+
+	interval := SlidingExpireInterval
+	if c.SlidingInterval > 0 {
+		interval = time.Duration(c.SlidingInterval) * time.Second
+	}
+	if expire.Sub(sd.Expire) < interval {
+		return sd.Expire, false
+	}
+	return expire, true
+}
+
+// IdleTimeout return seconds to wait for next TCP request.
+func (c *ServerConfig) IdleTimeout() time.Duration {
+	if c.TCPIdleTimeout > 0 {
+		return time.Duration(c.TCPIdleTimeout) * time.Second
+	}
+	return SessionTimeOut
+}
+
+// ReadTimeout return seconds to read and answer a TCP request.
+func (c *ServerConfig) ReadTimeout() time.Duration {
+	if c.TCPReadTimeout > 0 {
+		return time.Duration(c.TCPReadTimeout) * time.Second
+	}
+	return SessionTimeOut
+}
@@ -0,0 +1,50 @@
+package whoson
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServerConfig_LoginTTL(t *testing.T) {
+	var tests = []struct {
+		config    ServerConfig
+		requested time.Duration
+		expected  time.Duration
+	}{
+		{ServerConfig{}, 0, StoreDataExpire},
+		{ServerConfig{}, time.Hour, time.Hour},
+		{ServerConfig{TTLDefault: 600}, 0, 10 * time.Minute},
+		{ServerConfig{TTLDefault: 600}, time.Hour, time.Hour},
+		{ServerConfig{TTLMin: 300}, time.Minute, 5 * time.Minute},
+		{ServerConfig{TTLMax: 3600}, 2 * time.Hour, time.Hour},
+		{ServerConfig{TTLDefault: 7200, TTLMax: 3600}, 0, time.Hour},
+	}
+	for _, tt := range tests {
+		actual := tt.config.LoginTTL(tt.requested)
+		if tt.expected != actual {
+			t.Fatalf("expected %v, actual %v, config %+v, requested %v", tt.expected, actual, tt.config, tt.requested)
+		}
+	}
+}
+
+func TestServerConfig_SlideExpire(t *testing.T) {
+	now := time.Now()
+	var tests = []struct {
+		config   ServerConfig
+		sd       StoreData
+		expected time.Time
+		ok       bool
+	}{
+		{ServerConfig{}, StoreData{Expire: now.Add(time.Minute), TTL: time.Hour}, now.Add(time.Hour), true},
+		{ServerConfig{}, StoreData{Expire: now.Add(59*time.Minute + 30*time.Second), TTL: time.Hour}, now.Add(59*time.Minute + 30*time.Second), false},
+		{ServerConfig{SlidingInterval: 10}, StoreData{Expire: now.Add(59*time.Minute + 30*time.Second), TTL: time.Hour}, now.Add(time.Hour), true},
+		{ServerConfig{MaxLifetime: 7200}, StoreData{Expire: now.Add(time.Minute), TTL: time.Hour, Created: now.Add(-90 * time.Minute)}, now.Add(30 * time.Minute), true},
+		{ServerConfig{MaxLifetime: 7200}, StoreData{Expire: now.Add(time.Minute), TTL: time.Hour, Created: now.Add(-2 * time.Hour)}, now.Add(time.Minute), false},
+	}
+	for _, tt := range tests {
+		actual, ok := tt.config.SlideExpire(&tt.sd, now)
+		if !tt.expected.Equal(actual) || tt.ok != ok {
+			t.Fatalf("expected %v %v, actual %v %v, config %+v", tt.expected, tt.ok, actual, ok, tt.config)
+		}
+	}
+}
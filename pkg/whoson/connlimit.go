@@ -0,0 +1,156 @@
+package whoson
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// tcpConns count TCP sessions of all TCP servers.
+	tcpConns = newConnLimiter()
+
+	errTooManyConns      = errors.New("too many connections")
+	errTooManyConnsPerIP = errors.New("too many connections from address")
+)
+
+// connLimiter count active sessions in total and for each IP.
+type connLimiter struct {
+	mu    sync.Mutex
+	total int
+	perIP map[string]int
+}
+
+func newConnLimiter() *connLimiter {
+	return &connLimiter{
+		perIP: make(map[string]int),
+	}
+}
+
+// acquire count a session of ip, nil ip is counted only in total.
+func (l *connLimiter) acquire(ip net.IP, max, maxPerIP int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max > 0 && l.total >= max {
+		return errTooManyConns
+	}
+	if ip != nil {
+		key := ip.String()
+		if maxPerIP > 0 && l.perIP[key] >= maxPerIP {
+			return errTooManyConnsPerIP
+		}
+		l.perIP[key]++
+	}
+	l.total++
+	return nil
+}
+
+func (l *connLimiter) release(ip net.IP) {
+	l.mu.Lock()
+	l.total--
+	l.mu.Unlock()
+	if ip != nil {
+		l.releaseIP(ip)
+	}
+}
+
+// releaseIP uncount a session of ip counted by acquireIP.
+func (l *connLimiter) releaseIP(ip net.IP) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := ip.String()
+	l.perIP[key]--
+	if l.perIP[key] <= 0 {
+		delete(l.perIP, key)
+	}
+}
+
+// acquireIP count a session already counted in total with nil ip, for ip.
+func (l *connLimiter) acquireIP(ip net.IP, maxPerIP int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := ip.String()
+	if maxPerIP > 0 && l.perIP[key] >= maxPerIP {
+		return errTooManyConnsPerIP
+	}
+	l.perIP[key]++
+	return nil
+}
+
+// acceptConn count the accepted connection before its session is started,
+// and reject it when TCPMaxConns or TCPMaxConnsPerIP is reached. It return
+// IP counted for TCPMaxConnsPerIP.
+func (s *TCPServer) acceptConn(conn net.Conn) (net.IP, bool) {
+	config := getServerConfig()
+	ip := limitIP(conn)
+	err := tcpConns.acquire(ip, config.TCPMaxConns, config.TCPMaxConnsPerIP)
+	if err == nil {
+		return ip, true
+	}
+	expConnsRejectedTotal.Add(1)
+	Log("warn", "acceptConn:Rejected", nil, err)
+	// the response is not sent before TLS handshake.
+	if s.TLSConfig == nil {
+		if derr := conn.SetWriteDeadline(time.Now().Add(config.ReadTimeout())); derr == nil {
+			conn.Write([]byte(result[rBadRequest] + err.Error() + charCRLF + charCRLF))
+		}
+	}
+	conn.Close()
+	return nil, false
+}
+
+// acquireClientIP count the session for client IP of PROXY protocol header,
+// and reject it when TCPMaxConnsPerIP is reached. It return IP counted, nil
+// when conn is not from trusted proxy or the header has no client address.
+func acquireClientIP(ses *Session, raw, conn net.Conn) (net.IP, bool) {
+	ip := connIP(conn)
+	if !isTrustedProxy(raw.RemoteAddr()) || ip == nil || ip.Equal(connIP(raw)) {
+		return nil, true
+	}
+	config := getServerConfig()
+	err := tcpConns.acquireIP(ip, config.TCPMaxConnsPerIP)
+	if err == nil {
+		return ip, true
+	}
+	expConnsRejectedTotal.Add(1)
+	Log("warn", "acquireClientIP:Rejected", ses, err)
+	if derr := conn.SetDeadline(time.Now().Add(config.ReadTimeout())); derr == nil {
+		ses.sendResponseBadRequest(err.Error())
+	}
+	return nil, false
+}
+
+// limitIP return IP of raw conn counted for TCPMaxConnsPerIP, nil for unix
+// sockets and trusted proxies, whose client IP is counted after the PROXY
+// protocol header is read.
+func limitIP(conn net.Conn) net.IP {
+	if isTrustedProxy(conn.RemoteAddr()) {
+		return nil
+	}
+	return connIP(conn)
+}
+
+// connIP return IP of TCP remote address, nil for unix sockets.
+func connIP(conn net.Conn) net.IP {
+	if a, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return a.IP
+	}
+	return nil
+}
+
+// setKeepAlive apply TCPKeepAlive to conn, zero keeps the default.
+func setKeepAlive(conn net.Conn, period int) error {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok || period == 0 {
+		return nil
+	}
+	if period < 0 {
+		return tc.SetKeepAlive(false)
+	}
+	if err := tc.SetKeepAlive(true); err != nil {
+		return err
+	}
+	return tc.SetKeepAlivePeriod(time.Duration(period) * time.Second)
+}
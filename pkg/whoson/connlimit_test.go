@@ -0,0 +1,135 @@
+package whoson
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnLimiter(t *testing.T) {
+	l := newConnLimiter()
+	ip1 := net.ParseIP("192.0.2.1")
+	ip2 := net.ParseIP("192.0.2.2")
+
+	var tests = []struct {
+		ip       net.IP
+		expected error
+	}{
+		{ip1, nil},
+		{ip1, nil},
+		{ip1, errTooManyConnsPerIP},
+		{ip2, nil},
+		{nil, errTooManyConns},
+	}
+	for i, tt := range tests {
+		if err := l.acquire(tt.ip, 3, 2); err != tt.expected {
+			t.Fatalf("%d: expected %v, actual %v", i, tt.expected, err)
+		}
+	}
+	l.release(ip1)
+	if err := l.acquire(nil, 3, 2); err != nil {
+		t.Fatalf("expected nil, actual %v", err)
+	}
+	l.release(nil)
+	l.release(ip1)
+	l.release(ip2)
+	if l.total != 0 || len(l.perIP) != 0 {
+		t.Fatalf("expected empty, actual total %d perIP %v", l.total, l.perIP)
+	}
+}
+
+func TestServeTCP_MaxConnsPerIP(t *testing.T) {
+	NewLogger("discard", "error")
+	err := SetServerConfig(&ServerConfig{TCPMaxConnsPerIP: 1})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer SetServerConfig(nil)
+
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- ServeTCP(l) }()
+	defer func() {
+		l.Close()
+		<-done
+	}()
+
+	client, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if _, err := client.Query("192.0.2.1"); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if expected := "*too many connections from address\r\n"; line != expected {
+		t.Fatalf("expected %q, actual %q", expected, line)
+	}
+	client.Close()
+}
+
+func TestServeTCP_MaxConnsBeforeProxy(t *testing.T) {
+	NewLogger("discard", "error")
+	err := SetServerConfig(&ServerConfig{TCPMaxConns: 2, TCPMaxConnsPerIP: 1, TrustedProxies: []string{"127.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer SetServerConfig(nil)
+
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- ServeTCP(l) }()
+	defer func() {
+		l.Close()
+		<-done
+	}()
+
+	readLine := func(conn net.Conn) string {
+		conn.SetDeadline(time.Now().Add(time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		return line
+	}
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	// sessions waiting for PROXY protocol header hold the connection slots.
+	if line, expected := readLine(conns[2]), "*too many connections\r\n"; line != expected {
+		t.Fatalf("expected %q, actual %q", expected, line)
+	}
+
+	header := "PROXY TCP4 192.0.2.7 127.0.0.1 1000 9876\r\n"
+	conns[0].Write([]byte(header + "QUERY 192.0.2.1\r\n\r\n"))
+	if line := readLine(conns[0]); line[0] != '-' {
+		t.Fatalf("expected no data response, actual %q", line)
+	}
+	conns[1].Write([]byte(header))
+	if line, expected := readLine(conns[1]), "*too many connections from address\r\n"; line != expected {
+		t.Fatalf("expected %q, actual %q", expected, line)
+	}
+}
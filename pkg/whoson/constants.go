@@ -22,8 +22,14 @@ type ResultType int
 
 // ClientConfig hold information for client configration.
 type ClientConfig struct {
-	Mode   string
-	Server string
+	Mode      string
+	Server    string
+	AuthKeyID string
+	AuthKey   string
+	// TLSCA, TLSCert and TLSKey are used in "tls" mode.
+	TLSCA   string
+	TLSCert string
+	TLSKey  string
 }
 
 // ServerCtlConfig hold information for serverctl configration.
@@ -31,12 +37,20 @@ type ServerCtlConfig struct {
 	Server     string
 	JSON       bool
 	EditConfig bool
+	// APIToken is sent to whoson gRPC service, which needs APITokens of server.
+	APIToken string
 }
 
 // ServerConfig hold information for server configration.
 type ServerConfig struct {
-	TCP         string
-	UDP         string
+	TCP string
+	UDP string
+	// Unix and Unixgram are unix domain socket paths, not started when empty.
+	// Their clients are not checked by ACL and RateLimits, access is
+	// controlled by UnixPerm of the socket files.
+	Unix        string
+	Unixgram    string
+	UnixPerm    string
 	Log         string
 	Loglevel    string
 	ServerID    int
@@ -44,6 +58,83 @@ type ServerConfig struct {
 	ControlPort string
 	SyncRemote  string
 	SaveFile    string
+	TTLDefault  int
+	TTLMin      int
+	TTLMax      int
+	// syncHosts are peers of SyncRemote.
+	syncHosts []string
+
+	SlidingExpire   bool
+	SlidingInterval int
+	MaxLifetime     int
+
+	AuthKeys     map[string]string
+	AuthRequired bool
+	AuthWindow   int
+
+	ACL []ACLRule
+	acl accessList
+
+	RateLimits          []RateLimit
+	RateLimitDrop       bool
+	RateLimitMaxEntries int
+	limiter             *rateLimiter
+
+	HistoryFile      string
+	HistoryRetention int
+
+	// TrustedProxies are networks of proxies sending PROXY protocol v1/v2 header on TCP.
+	TrustedProxies []string
+	proxies        trustedProxies
+
+	// TLSCert and TLSKey enable TLS on TCP, and TLSClientCA requires client certificate.
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string
+
+	// TCPIdleTimeout is seconds to wait for next request, and TCPReadTimeout is
+	// seconds to read and answer a request after its first byte.
+	TCPIdleTimeout int
+	TCPReadTimeout int
+	// TCPMaxConns and TCPMaxConnsPerIP limit concurrent TCP sessions, unlimited when zero.
+	TCPMaxConns      int
+	TCPMaxConnsPerIP int
+	// TCPKeepAlive is keepalive period seconds, negative disables keepalive.
+	TCPKeepAlive int
+
+	// ShutdownTimeout is seconds to wait for active sessions on shutdown.
+	ShutdownTimeout int
+
+	// Hooks are commands run on store events by HookWorkers goroutines,
+	// and killed after HookTimeout seconds.
+	Hooks       []Hook
+	HookWorkers int
+	HookTimeout int
+	hooks       hookList
+
+	// Webhooks are URLs receiving store events by POST from WebhookWorkers
+	// goroutines, HookWorkers when 0. Failed requests are retried WebhookRetries
+	// times with backoff, and each request is limited to WebhookTimeout seconds.
+	Webhooks       []Webhook
+	WebhookWorkers int
+	WebhookRetries int
+	WebhookTimeout int
+	webhooks       webhookList
+
+	// API is listen address of HTTP API, not started when empty. Requests need
+	// "Authorization: Bearer <token>" of APITokens when APITokens is set.
+	API       string
+	APITokens map[string]string
+
+	// Metrics is listen address of Prometheus metrics on MetricsPath and expvar,
+	// which replaces ":8080" of Expvar, not started when empty.
+	Metrics     string
+	MetricsPath string
+
+	// Tracing is exporter of OpenTelemetry spans, "otlp" or "file", not traced
+	// when empty. TracingEndpoint is OTLP gRPC endpoint URL or file path.
+	Tracing         string
+	TracingEndpoint string
 }
 
 const (
@@ -57,15 +148,49 @@ const (
 		"64<<10"   65536
 		" 1<<20" 1048576 1M
 	*/
-	maxQueues   = 8 << 10
-	udpByteSize = 1472
-	charCRLF    = "\r\n"
+	maxQueues           = 8 << 10
+	maxRateLimitEntries = 64 << 10
+	udpByteSize         = 1472
+	hookQueueSize       = 1 << 10
+	webhookQueueSize    = 1 << 10
+	watchQueueSize      = 1 << 10
+	charCRLF            = "\r\n"
 	// SessionTimeOut is tcp session timeout limit.
 	SessionTimeOut = 10 * time.Second
+	// ShutdownTimeout is grace period for active sessions on shutdown.
+	ShutdownTimeout = 8 * time.Second
 	// StoreDataExpire is stored data expire limit.
 	StoreDataExpire = 30 * time.Minute
 	// ExpireCheckInterval is expire check interval for stored data.
 	ExpireCheckInterval = 5 * time.Minute
+	// SlidingExpireInterval is minimum expire extension stored by sliding expire.
+	SlidingExpireInterval = 1 * time.Minute
+
+	// HistoryRetention is default retention period of login history.
+	HistoryRetention = 90 * 24 * time.Hour
+	// HistoryPurgeInterval is purge interval for login history.
+	HistoryPurgeInterval = 1 * time.Hour
+
+	// HookWorkers is default number of goroutines running hooks.
+	HookWorkers = 4
+	// HookTimeout is default time limit of a hook command.
+	HookTimeout = 10 * time.Second
+	// WebhookRetries is default number of webhook retries.
+	WebhookRetries = 3
+	// WebhookTimeout is default time limit of a webhook request.
+	WebhookTimeout = 5 * time.Second
+
+	// MetricsPath is default path of Prometheus metrics.
+	MetricsPath = "/metrics"
+
+	// AuthWindow is allowed time difference of signed command timestamp.
+	AuthWindow = 1 * time.Minute
+
+	ttlArgPrefix        = "ttl="
+	authTimestampPrefix = "ts="
+	authNoncePrefix     = "nonce="
+	authKeyIDPrefix     = "keyid="
+	authSignaturePrefix = "sig="
 
 	pUnkownProtocol ProtocolType = iota
 	pTCP
@@ -76,6 +201,8 @@ const (
 	mLogout
 	mQuery
 	mQuit
+	mRefresh
+	mLookup
 
 	rPositive ResultType = iota
 	rNegative
@@ -99,15 +226,25 @@ var (
 	ExpvarMap = expvar.NewMap("gowhoson")
 
 	// Raw stat collectors
-	expConnectsTCPTotal   = new(expvar.Int)
-	expConnectsUDPTotal   = new(expvar.Int)
-	expConnectsTCPCurrent = new(expvar.Int)
-	expConnectsUDPCurrent = new(expvar.Int)
-	expCommandLoginTotal  = new(expvar.Int)
-	expCommandLogoutTotal = new(expvar.Int)
-	expCommandQueryTotal  = new(expvar.Int)
-	expCommandQuitTotal   = new(expvar.Int)
-	expErrorsTotal        = new(expvar.Int)
+	expConnectsTCPTotal     = new(expvar.Int)
+	expConnectsUDPTotal     = new(expvar.Int)
+	expConnectsTCPCurrent   = new(expvar.Int)
+	expConnectsUDPCurrent   = new(expvar.Int)
+	expCommandLoginTotal    = new(expvar.Int)
+	expCommandLogoutTotal   = new(expvar.Int)
+	expCommandQueryTotal    = new(expvar.Int)
+	expCommandQuitTotal     = new(expvar.Int)
+	expCommandRefreshTotal  = new(expvar.Int)
+	expCommandLookupTotal   = new(expvar.Int)
+	expErrorsTotal          = new(expvar.Int)
+	expAuthFailuresTotal    = new(expvar.Int)
+	expACLDeniedTotal       = new(expvar.Int)
+	expRateLimitedTotal     = new(expvar.Int)
+	expConnsRejectedTotal   = new(expvar.Int)
+	expHookErrorsTotal      = new(expvar.Int)
+	expHooksDroppedTotal    = new(expvar.Int)
+	expWebhookErrorsTotal   = new(expvar.Int)
+	expWebhooksDroppedTotal = new(expvar.Int)
 
 	method = map[MethodType]string{
 		mUnkownMethod: "NONE",
@@ -115,6 +252,8 @@ var (
 		mLogout:       "LOGOUT",
 		mQuery:        "QUERY",
 		mQuit:         "QUIT",
+		mRefresh:      "REFRESH",
+		mLookup:       "LOOKUP",
 	}
 
 	result = map[ResultType]string{
@@ -130,6 +269,7 @@ func init() {
 	for i, v := range method {
 		methodFromString[v] = i
 	}
+	methodFromString["TOUCH"] = mRefresh
 
 	ExpvarMap.Set("ConnectsTCPTotal", expConnectsTCPTotal)
 	ExpvarMap.Set("ConnectsUDPTotal", expConnectsUDPTotal)
@@ -139,7 +279,20 @@ func init() {
 	ExpvarMap.Set("CommandLogoutTotal", expCommandLogoutTotal)
 	ExpvarMap.Set("CommandQueryTotal", expCommandQueryTotal)
 	ExpvarMap.Set("CommandQuitTotal", expCommandQuitTotal)
+	ExpvarMap.Set("CommandRefreshTotal", expCommandRefreshTotal)
+	ExpvarMap.Set("CommandLookupTotal", expCommandLookupTotal)
 	ExpvarMap.Set("ErrorsTotal", expErrorsTotal)
+	ExpvarMap.Set("AuthFailuresTotal", expAuthFailuresTotal)
+	ExpvarMap.Set("ACLDeniedTotal", expACLDeniedTotal)
+	ExpvarMap.Set("RateLimitedTotal", expRateLimitedTotal)
+	ExpvarMap.Set("ConnsRejectedTotal", expConnsRejectedTotal)
+	ExpvarMap.Set("HookErrorsTotal", expHookErrorsTotal)
+	ExpvarMap.Set("HooksDroppedTotal", expHooksDroppedTotal)
+	ExpvarMap.Set("HookQueueLength", expvar.Func(func() interface{} { return len(hookQueue) }))
+	ExpvarMap.Set("WebhookErrorsTotal", expWebhookErrorsTotal)
+	ExpvarMap.Set("WebhooksDroppedTotal", expWebhooksDroppedTotal)
+	ExpvarMap.Set("WebhookQueueLength", expvar.Func(func() interface{} { return len(webhookQueue) }))
+	ExpvarMap.Set("Listeners", expListeners)
 	ExpvarMap.Set("Goroutines", expvar.Func(func() interface{} { return runtime.NumGoroutine() }))
 	ExpvarMap.Set("NumCPU", expvar.Func(func() interface{} { return runtime.NumCPU() }))
 	ExpvarMap.Set("OSThreads", expvar.Func(func() interface{} { return pprof.Lookup("threadcreate").Count() }))
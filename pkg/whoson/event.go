@@ -0,0 +1,48 @@
+package whoson
+
+import (
+	"net"
+	"time"
+)
+
+const (
+	// eventLocal is source of events by whoson commands and expiry of this server.
+	eventLocal = "local"
+	// eventSync is source of events applied from remote servers by SyncRemote.
+	eventSync = "sync"
+)
+
+// StoreEvent hold information for a change of store data.
+type StoreEvent struct {
+	Time   time.Time
+	Event  string
+	Source string
+	IP     string
+	Data   string
+	Expire time.Time
+	ip     net.IP
+}
+
+func newStoreEvent(event string, source string, sd *StoreData) *StoreEvent {
+	return &StoreEvent{
+		Time:   time.Now(),
+		Event:  event,
+		Source: source,
+		IP:     sd.Key(),
+		Data:   sd.Data,
+		Expire: sd.Expire,
+		ip:     sd.IP,
+	}
+}
+
+// storeEvent record event of sd to history, queue hooks and webhooks of event, and send it to watchers.
+func storeEvent(event string, source string, sd *StoreData) {
+	if sd == nil {
+		return
+	}
+	recordHistory(event, sd)
+	ev := newStoreEvent(event, source, sd)
+	queueHooks(ev)
+	queueWebhooks(ev)
+	watchers.publish(ev)
+}
@@ -0,0 +1,487 @@
+package whoson
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MainHistory holds login history, history is not recorded when nil.
+var MainHistory *History
+
+const (
+	hLogin   = "Login"
+	hLogout  = "Logout"
+	hExpire  = "Expire"
+	hRefresh = "Refresh"
+	// hActive is event of login continuing from the previous day, at the head of a day file.
+	hActive = "Active"
+
+	historyDayLayout = "2006-01-02"
+)
+
+// historyEvent is one line of history file.
+type historyEvent struct {
+	Time   time.Time
+	Event  string
+	IP     string
+	Data   string `json:",omitempty"`
+	Expire time.Time
+}
+
+// HistoryRecord hold information for a past or current login.
+type HistoryRecord struct {
+	IP     string
+	Data   string
+	Login  time.Time
+	Logout time.Time
+}
+
+// History hold information for login history, appended to a file of each day
+// in UTC, named HistoryFile with suffix of the date. Each day file starts with
+// Active events of logins continuing from the previous day, so that a lookup
+// reads only the day files of its time range.
+type History struct {
+	mu        sync.Mutex
+	file      string
+	retention time.Duration
+	f         *os.File
+	day       time.Time
+	active    map[string]*HistoryRecord
+}
+
+// NewHistory return new History appending to day files of file, day files older
+// than retention are purged. Events of file written by previous versions are
+// moved to day files.
+func NewHistory(file string, retention time.Duration) (*History, error) {
+	if retention <= 0 {
+		retention = HistoryRetention
+	}
+	h := &History{
+		file:      file,
+		retention: retention,
+		active:    make(map[string]*HistoryRecord),
+	}
+	now := time.Now()
+	if err := h.migrate(); err != nil {
+		return nil, err
+	}
+	if err := h.load(now); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.rotate(now); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// NewMainHistory set History to MainHistory.
+func NewMainHistory(file string, retention time.Duration) error {
+	h, err := NewHistory(file, retention)
+	if err != nil {
+		return err
+	}
+	MainHistory = h
+	return nil
+}
+
+// historyDay return start of the day of t in UTC.
+func historyDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func (h *History) dayFile(day time.Time) string {
+	return h.file + "." + day.Format(historyDayLayout)
+}
+
+// days return sorted days of existing day files.
+func (h *History) days() ([]time.Time, error) {
+	entries, err := os.ReadDir(filepath.Dir(h.file))
+	if err != nil {
+		return nil, errors.Wrap(err, "history read error")
+	}
+	prefix := filepath.Base(h.file) + "."
+	var days []time.Time
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		if day, err := time.Parse(historyDayLayout, name); err == nil {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+	return days, nil
+}
+
+// rotate switch the file to day file of now, a new day file starts with
+// Active events of logins not expired at now. h.mu must be held.
+func (h *History) rotate(now time.Time) error {
+	day := historyDay(now)
+	if h.f != nil && day.Equal(h.day) {
+		return nil
+	}
+	file := h.dayFile(day)
+	_, err := os.Stat(file)
+	created := os.IsNotExist(err)
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "history open error")
+	}
+	if created {
+		if err := h.writeActive(f, now); err != nil {
+			f.Close()
+			return errors.Wrap(err, "history write error")
+		}
+	}
+	if h.f != nil {
+		h.f.Close()
+	}
+	h.f = f
+	h.day = day
+	return nil
+}
+
+func (h *History) writeActive(f *os.File, now time.Time) error {
+	keys := make([]string, 0, len(h.active))
+	for k, r := range h.active {
+		if !r.Logout.After(now) {
+			delete(h.active, k)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, k := range keys {
+		r := h.active[k]
+		if err := enc.Encode(&historyEvent{Time: r.Login, Event: hActive, IP: r.IP, Data: r.Data, Expire: r.Logout}); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// track update logins continuing to the next day file by event of sd.
+func (h *History) track(event string, sd *StoreData, now time.Time) {
+	key := sd.Key()
+	switch event {
+	case hLogin:
+		h.active[key] = &HistoryRecord{IP: key, Data: sd.Data, Login: now, Logout: sd.Expire}
+	case hRefresh:
+		if r, ok := h.active[key]; ok {
+			r.Logout = sd.Expire
+		}
+	case hLogout, hExpire:
+		delete(h.active, key)
+	}
+}
+
+// load set logins not expired at now from the last day file.
+func (h *History) load(now time.Time) error {
+	days, err := h.days()
+	if err != nil || len(days) == 0 {
+		return err
+	}
+	b := newHistoryBuilder()
+	if err := h.readDay(days[len(days)-1], b.add); err != nil {
+		return err
+	}
+	for k, r := range b.current {
+		if r.Logout.After(now) {
+			h.active[k] = r
+		}
+	}
+	return nil
+}
+
+// migrate move events of history file of previous versions to day files.
+func (h *History) migrate() error {
+	f, err := os.Open(h.file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "history migrate error")
+	}
+	defer f.Close()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		e := &historyEvent{}
+		if err := json.Unmarshal(s.Bytes(), e); err != nil {
+			continue
+		}
+		if err := h.rotate(e.Time); err != nil {
+			return err
+		}
+		key, err := parseKey(e.IP)
+		if err == nil {
+			key.Data = e.Data
+			key.Expire = e.Expire
+			h.track(e.Event, key, e.Time)
+		}
+		if _, err := h.f.Write(append(s.Bytes(), '\n')); err != nil {
+			return errors.Wrap(err, "history migrate error")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return errors.Wrap(err, "history migrate error")
+	}
+	if h.f != nil {
+		h.f.Close()
+		h.f = nil
+	}
+	return os.Remove(h.file)
+}
+
+// Close closes history file.
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.f == nil {
+		return nil
+	}
+	return h.f.Close()
+}
+
+// record append event of sd to day file of now.
+func (h *History) record(event string, sd *StoreData, now time.Time) error {
+	b, err := json.Marshal(&historyEvent{
+		Time:   now,
+		Event:  event,
+		IP:     sd.Key(),
+		Data:   sd.Data,
+		Expire: sd.Expire,
+	})
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.rotate(now); err != nil {
+		return err
+	}
+	h.track(event, sd, now)
+	_, err = h.f.Write(append(b, '\n'))
+	return err
+}
+
+// readDay call f with each event of day file of day.
+func (h *History) readDay(day time.Time, f func(*historyEvent)) error {
+	file, err := os.Open(h.dayFile(day))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "history read error")
+	}
+	defer file.Close()
+
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		e := &historyEvent{}
+		if err := json.Unmarshal(s.Bytes(), e); err != nil {
+			continue
+		}
+		f(e)
+	}
+	return s.Err()
+}
+
+// historyBuilder build login records from history events in order.
+type historyBuilder struct {
+	records []*HistoryRecord
+	current map[string]*HistoryRecord
+}
+
+func newHistoryBuilder() *historyBuilder {
+	return &historyBuilder{current: make(map[string]*HistoryRecord)}
+}
+
+func (b *historyBuilder) add(e *historyEvent) {
+	r, ok := b.current[e.IP]
+	switch e.Event {
+	case hActive:
+		if !ok {
+			r = &HistoryRecord{IP: e.IP, Data: e.Data, Login: e.Time, Logout: e.Expire}
+			b.current[e.IP] = r
+			b.records = append(b.records, r)
+		}
+	case hLogin:
+		if ok && r.Logout.After(e.Time) {
+			r.Logout = e.Time
+		}
+		r = &HistoryRecord{IP: e.IP, Data: e.Data, Login: e.Time, Logout: e.Expire}
+		b.current[e.IP] = r
+		b.records = append(b.records, r)
+	case hRefresh:
+		if ok {
+			r.Logout = e.Expire
+		}
+	case hLogout, hExpire:
+		if ok {
+			if r.Logout.After(e.Time) {
+				r.Logout = e.Time
+			}
+			delete(b.current, e.IP)
+		}
+	}
+}
+
+// readRecords return login records built from day files of days.
+func (h *History) readRecords(days []time.Time) ([]*HistoryRecord, error) {
+	b := newHistoryBuilder()
+	for _, day := range days {
+		if err := h.readDay(day, b.add); err != nil {
+			return nil, err
+		}
+	}
+	return b.records, nil
+}
+
+// Records return login records built from all history events.
+func (h *History) Records() ([]*HistoryRecord, error) {
+	days, err := h.days()
+	if err != nil {
+		return nil, err
+	}
+	return h.readRecords(days)
+}
+
+// rangeRecords return login records built from day files of days from from to
+// to, and the last day file before from when there is no day file of from.
+// Logout of a record continuing after to is its expire time known at to.
+func (h *History) rangeRecords(from, to time.Time) ([]*HistoryRecord, error) {
+	days, err := h.days()
+	if err != nil {
+		return nil, err
+	}
+	first, last := historyDay(from), historyDay(to)
+	var selected []time.Time
+	for i, day := range days {
+		if day.After(last) {
+			break
+		}
+		if day.Before(first) && i+1 < len(days) && !days[i+1].After(first) {
+			continue
+		}
+		selected = append(selected, day)
+	}
+	return h.readRecords(selected)
+}
+
+// FindByIP return login records of ip, of its port ranges, or of prefix containing ip, at t.
+func (h *History) FindByIP(ip net.IP, t time.Time) ([]*HistoryRecord, error) {
+	return h.find(t, t, func(r *HistoryRecord) bool {
+		key, err := parseKey(r.IP)
+		if err != nil {
+			return false
+		}
+		if key.PrefixLen > 0 {
+			if !(&net.IPNet{IP: key.IP, Mask: net.CIDRMask(key.PrefixLen, len(key.IP)*8)}).Contains(ip) {
+				return false
+			}
+		} else if !key.IP.Equal(ip) {
+			return false
+		}
+		return !r.Login.After(t) && r.Logout.After(t)
+	})
+}
+
+// FindByUser return login records of user overlapping from and to.
+func (h *History) FindByUser(user string, from, to time.Time) ([]*HistoryRecord, error) {
+	return h.find(from, to, func(r *HistoryRecord) bool {
+		return dataUser(r.Data) == user && !r.Login.After(to) && r.Logout.After(from)
+	})
+}
+
+func (h *History) find(from, to time.Time, match func(*HistoryRecord) bool) ([]*HistoryRecord, error) {
+	records, err := h.rangeRecords(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var found []*HistoryRecord
+	for _, r := range records {
+		if match(r) {
+			found = append(found, r)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Login.Before(found[j].Login)
+	})
+	return found, nil
+}
+
+// Purge start day file of now, and remove day files of days ended before
+// retention from now. Recording is not blocked while files are removed.
+func (h *History) Purge(now time.Time) error {
+	h.mu.Lock()
+	err := h.rotate(now)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	days, err := h.days()
+	if err != nil {
+		return err
+	}
+	cutoff := now.Add(-h.retention)
+	for _, day := range days {
+		if day.AddDate(0, 0, 1).After(cutoff) {
+			break
+		}
+		if err := os.Remove(h.dayFile(day)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "history purge error")
+		}
+	}
+	return nil
+}
+
+// recordHistory append event of sd to MainHistory.
+func recordHistory(event string, sd *StoreData) {
+	if MainHistory == nil || sd == nil {
+		return
+	}
+	if err := MainHistory.record(event, sd, time.Now()); err != nil {
+		Log("error", "recordHistory:Error", nil, err)
+	}
+}
+
+// RunHistoryPurger purge old events of MainHistory periodically.
+func RunHistoryPurger(ctx context.Context) {
+	if MainHistory == nil {
+		return
+	}
+	t := time.NewTicker(HistoryPurgeInterval)
+	defer t.Stop()
+	Log("info", "runHistoryPurgerStart", nil, nil)
+	for {
+		select {
+		case <-ctx.Done():
+			MainHistory.Close()
+			Log("info", "runHistoryPurgerStop", nil, nil)
+			return
+		case <-t.C:
+			if err := MainHistory.Purge(time.Now()); err != nil {
+				Log("error", "runHistoryPurger:Error", nil, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package whoson
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestHistory(t *testing.T) (*History, func(int) time.Time) {
+	h, err := NewHistory(filepath.Join(t.TempDir(), "history.json"), 0)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	record := func(event, addr, data string, m, expire int) {
+		ip, prefixLen, _ := parseAddr(addr)
+		sd := &StoreData{IP: ip, PrefixLen: prefixLen, Data: data, Expire: at(expire)}
+		if err := h.record(event, sd, at(m)); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+	}
+	record(hLogin, "192.0.2.1", "user1", 0, 30)
+	record(hRefresh, "192.0.2.1", "user1", 20, 50)
+	record(hLogout, "192.0.2.1", "user1", 40, 50)
+	record(hLogin, "192.0.2.1", "user2", 60, 90)
+	record(hExpire, "192.0.2.1", "user2", 90, 90)
+	record(hLogin, "198.51.100.0/24", "user1", 100, 130)
+	return h, at
+}
+
+func TestHistory_FindByIP(t *testing.T) {
+	h, at := newTestHistory(t)
+	defer h.Close()
+
+	var tests = []struct {
+		ip       string
+		m        int
+		expected string
+	}{
+		{"192.0.2.1", 10, "user1"},
+		{"192.0.2.1", 35, "user1"},
+		{"192.0.2.1", 45, ""},
+		{"192.0.2.1", 70, "user2"},
+		{"192.0.2.1", 95, ""},
+		{"198.51.100.7", 110, "user1"},
+		{"198.51.100.7", 140, ""},
+	}
+	for _, tt := range tests {
+		records, err := h.FindByIP(net.ParseIP(tt.ip), at(tt.m))
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if tt.expected == "" {
+			if len(records) != 0 {
+				t.Fatalf("expected no record, ip %v at %d, actual %v", tt.ip, tt.m, records[0].Data)
+			}
+			continue
+		}
+		if len(records) != 1 || records[0].Data != tt.expected {
+			t.Fatalf("expected %v, ip %v at %d, actual %v", tt.expected, tt.ip, tt.m, records)
+		}
+	}
+}
+
+func TestHistory_FindByUser(t *testing.T) {
+	h, at := newTestHistory(t)
+	defer h.Close()
+
+	records, err := h.FindByUser("user1", at(0), at(200))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(records) != 2 || records[0].IP != "192.0.2.1" || !records[0].Logout.Equal(at(40)) || records[1].IP != "198.51.100.0/24" {
+		t.Fatalf("expected 2 records of user1, actual %v", records)
+	}
+	records, err = h.FindByUser("user1", at(45), at(95))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("expected no record, actual %v", records)
+	}
+}
+
+func TestHistory_DayFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.json")
+	h, err := NewHistory(file, 0)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer h.Close()
+
+	day := func(d, hour int) time.Time { return time.Date(2024, 1, d, hour, 0, 0, 0, time.UTC) }
+	record := func(event, ip, data string, at, expire time.Time) {
+		if err := h.record(event, &StoreData{IP: net.ParseIP(ip), Data: data, Expire: expire}, at); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+	}
+	record(hLogin, "192.0.2.1", "user1", day(1, 10), day(5, 0))
+	record(hLogin, "192.0.2.2", "user2", day(1, 11), day(1, 12))
+	record(hRefresh, "192.0.2.1", "user1", day(2, 10), day(6, 0))
+	record(hLogin, "192.0.2.3", "user3", day(4, 10), day(4, 12))
+	record(hLogout, "192.0.2.1", "user1", day(4, 11), day(6, 0))
+
+	// day 2 file starts with Active user1, so a lookup on day 2 and 3 does not need day 1 file.
+	if err := os.Remove(h.dayFile(historyDay(day(1, 0)))); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	var tests = []struct {
+		t        time.Time
+		expected []string
+	}{
+		{day(2, 12), []string{"user1"}},
+		{day(3, 12), []string{"user1"}},
+		{day(4, 10), []string{"user1"}},
+		{day(4, 12), nil},
+	}
+	for _, tt := range tests {
+		records, err := h.FindByIP(net.ParseIP("192.0.2.1"), tt.t)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if len(records) != len(tt.expected) || len(records) == 1 && (records[0].Data != tt.expected[0] || !records[0].Login.Equal(day(1, 10))) {
+			t.Fatalf("at %v expected %v, actual %v", tt.t, tt.expected, records)
+		}
+	}
+	records, err := h.FindByUser("user1", day(4, 0), day(4, 23))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(records) != 1 || !records[0].Logout.Equal(day(4, 11)) {
+		t.Fatalf("expected user1 logout at %v, actual %v", day(4, 11), records)
+	}
+}
+
+func TestHistory_Migrate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.json")
+	legacy := `{"Time":"2024-01-01T10:00:00Z","Event":"Login","IP":"192.0.2.1","Data":"user1","Expire":"2024-01-03T00:00:00Z"}
+{"Time":"2024-01-02T10:00:00Z","Event":"Logout","IP":"192.0.2.1","Data":"user1","Expire":"2024-01-03T00:00:00Z"}
+`
+	if err := os.WriteFile(file, []byte(legacy), 0600); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	h, err := NewHistory(file, 0)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer h.Close()
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("history file of previous version should be removed, %v", err)
+	}
+	records, err := h.FindByIP(net.ParseIP("192.0.2.1"), time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(records) != 1 || !records[0].Logout.Equal(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected migrated record, actual %v", records)
+	}
+}
+
+func TestHistory_Purge(t *testing.T) {
+	h, err := NewHistory(filepath.Join(t.TempDir(), "history.json"), 48*time.Hour)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer h.Close()
+
+	now := time.Now()
+	h.record(hLogin, &StoreData{IP: net.ParseIP("192.0.2.1"), Data: "user1", Expire: now.Add(-95 * time.Hour)}, now.Add(-96*time.Hour))
+	sd := &StoreData{IP: net.ParseIP("192.0.2.1"), Data: "user1", Expire: now.Add(time.Hour)}
+	h.record(hLogin, sd, now.Add(-24*time.Hour))
+	if err := h.Purge(now); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	h.record(hLogout, sd, now)
+
+	days, err := h.days()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(days) != 2 || !days[0].Equal(historyDay(now.Add(-24*time.Hour))) || !days[1].Equal(historyDay(now)) {
+		t.Fatalf("expected day files of yesterday and today after purge, actual %v", days)
+	}
+	records, err := h.Records()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if len(records) != 1 || !records[0].Logout.Equal(now) {
+		t.Fatalf("expected 1 record after purge, actual %v", records)
+	}
+}
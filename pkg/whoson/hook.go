@@ -0,0 +1,161 @@
+package whoson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// hookQueue hold hooks waiting for RunHookWorkers.
+var hookQueue = make(chan *hookJob, hookQueueSize)
+
+// Hook hold information for a command run on store events.
+type Hook struct {
+	// Events are "Login", "Logout", "Expire" and "Refresh".
+	Events []string
+	// Command is program and arguments, run without shell.
+	Command []string
+}
+
+// hookList is compiled Hook list by event.
+type hookList map[string][]*Hook
+
+type hookJob struct {
+	hook  *Hook
+	event *StoreEvent
+}
+
+func newHookList(hooks []Hook) (hookList, error) {
+	hl := make(hookList)
+	for i := range hooks {
+		h := &hooks[i]
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return nil, errors.New("hook command is empty")
+		}
+		if len(h.Events) == 0 {
+			return nil, errors.Errorf("hook %q has no events", h.Command[0])
+		}
+		for _, e := range h.Events {
+			switch e {
+			case hLogin, hLogout, hExpire, hRefresh:
+				hl[e] = append(hl[e], h)
+			default:
+				return nil, errors.Errorf("hook %q event %q not found", h.Command[0], e)
+			}
+		}
+	}
+	return hl, nil
+}
+
+// queueHooks queue hooks of ev without blocking, ev is dropped when the queue is full.
+func queueHooks(ev *StoreEvent) {
+	for _, h := range getServerConfig().hooks[ev.Event] {
+		select {
+		case hookQueue <- &hookJob{hook: h, event: ev}:
+		default:
+			expHooksDroppedTotal.Add(1)
+			Log("warn", fmt.Sprintf("queueHooks:Dropped %s %s", ev.Event, ev.IP), nil, nil)
+		}
+	}
+}
+
+// HookTimeLimit return time limit of a hook command.
+func (c *ServerConfig) HookTimeLimit() time.Duration {
+	if c.HookTimeout > 0 {
+		return time.Duration(c.HookTimeout) * time.Second
+	}
+	return HookTimeout
+}
+
+// environ return environment variables describing ev for hook commands.
+func (ev *StoreEvent) environ() []string {
+	return []string{
+		"GOWHOSON_EVENT=" + ev.Event,
+		"GOWHOSON_SOURCE=" + ev.Source,
+		"GOWHOSON_IP=" + ev.IP,
+		"GOWHOSON_DATA=" + ev.Data,
+		"GOWHOSON_EXPIRE=" + ev.Expire.Format(time.RFC3339),
+	}
+}
+
+// run execute hook command with the event as environment variables and JSON on stdin,
+// the command is killed after HookTimeout.
+func (j *hookJob) run(ctx context.Context) error {
+	b, err := json.Marshal(j.event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, getServerConfig().HookTimeLimit())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, j.hook.Command[0], j.hook.Command[1:]...)
+	cmd.Env = append(os.Environ(), j.event.environ()...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "hook %q %s %s: %s", j.hook.Command[0], j.event.Event, j.event.IP, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (j *hookJob) exec(ctx context.Context) {
+	if err := j.run(ctx); err != nil {
+		expHookErrorsTotal.Add(1)
+		Log("error", "runHook:Error", nil, err)
+		return
+	}
+	Log("debug", fmt.Sprintf("runHook:%s %s %s", j.hook.Command[0], j.event.Event, j.event.IP), nil, nil)
+}
+
+// RunHookWorkers run queued hooks by workers goroutines, so that slow hooks
+// never block whoson sessions. Queued hooks are run for HookTimeout after ctx is done.
+func RunHookWorkers(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = HookWorkers
+	}
+	Log("info", "runHookWorkersStart", nil, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hookWorker(ctx)
+		}()
+	}
+	wg.Wait()
+	Log("info", "runHookWorkersStop", nil, nil)
+}
+
+func hookWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			drainHooks(time.Now().Add(getServerConfig().HookTimeLimit()))
+			return
+		case j := <-hookQueue:
+			j.exec(context.Background())
+		}
+	}
+}
+
+// drainHooks run queued hooks until the queue is empty or deadline.
+func drainHooks(deadline time.Time) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	for ctx.Err() == nil {
+		select {
+		case j := <-hookQueue:
+			j.exec(ctx)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package whoson
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHookList(t *testing.T) {
+	var tests = []struct {
+		hooks []Hook
+		ok    bool
+	}{
+		{nil, true},
+		{[]Hook{{Events: []string{"Login", "Logout", "Expire"}, Command: []string{"/bin/true"}}}, true},
+		{[]Hook{{Events: []string{"Login"}, Command: nil}}, false},
+		{[]Hook{{Events: nil, Command: []string{"/bin/true"}}}, false},
+		{[]Hook{{Events: []string{"LOGIN"}, Command: []string{"/bin/true"}}}, false},
+	}
+	for i, tt := range tests {
+		_, err := newHookList(tt.hooks)
+		if (err == nil) != tt.ok {
+			t.Fatalf("%d: expected ok %v, actual %v", i, tt.ok, err)
+		}
+	}
+}
+
+func TestRunHookWorkers(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	dir := t.TempDir()
+	err := SetServerConfig(&ServerConfig{
+		Hooks: []Hook{
+			{Events: []string{hLogin}, Command: []string{"/bin/sh", "-c", `cat > "$0/$GOWHOSON_EVENT.json"`, dir}},
+			{Events: []string{hLogin, hExpire}, Command: []string{"/bin/sh", "-c", `echo "$GOWHOSON_SOURCE $GOWHOSON_IP $GOWHOSON_DATA" > "$0/$GOWHOSON_EVENT.env"`, dir}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunHookWorkers(ctx, 2)
+		close(done)
+	}()
+	sd := &StoreData{IP: net.ParseIP("192.0.2.1"), Data: "user1", Expire: time.Now()}
+	storeEvent(hLogin, eventLocal, sd)
+	storeEvent(hLogout, eventLocal, sd)
+	storeEvent(hExpire, eventSync, sd)
+	cancel()
+	<-done
+
+	b, err := os.ReadFile(filepath.Join(dir, "Login.json"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	ev := &StoreEvent{}
+	if err := json.Unmarshal(b, ev); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if ev.Event != hLogin || ev.Source != eventLocal || ev.IP != "192.0.2.1" || ev.Data != "user1" {
+		t.Fatalf("unexpected stdin %s", b)
+	}
+	var envs = []struct {
+		file     string
+		expected string
+	}{
+		{"Login.env", "local 192.0.2.1 user1"},
+		{"Expire.env", "sync 192.0.2.1 user1"},
+	}
+	for _, tt := range envs {
+		b, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if strings.TrimSpace(string(b)) != tt.expected {
+			t.Fatalf("%s: expected %q, actual %q", tt.file, tt.expected, b)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Logout.env")); !os.IsNotExist(err) {
+		t.Fatalf("hook of other events should not be run")
+	}
+}
+
+func TestHookJob_Timeout(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{HookTimeout: 1})
+
+	j := &hookJob{
+		hook:  &Hook{Command: []string{"/bin/sleep", "10"}},
+		event: &StoreEvent{Event: hLogin},
+	}
+	start := time.Now()
+	if err := j.run(context.Background()); err == nil {
+		t.Fatalf("slow hook should be killed")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("hook was killed after %v", d)
+	}
+}
+
+func TestQueueHooks_Dropped(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{
+		Hooks: []Hook{{Events: []string{hLogin}, Command: []string{"/bin/true"}}},
+	})
+
+	dropped := expHooksDroppedTotal.Value()
+	ev := &StoreEvent{Event: hLogin}
+	for i := 0; i < hookQueueSize+1; i++ {
+		queueHooks(ev)
+	}
+	if len(hookQueue) != hookQueueSize {
+		t.Fatalf("expected queue length %d, actual %d", hookQueueSize, len(hookQueue))
+	}
+	if expHooksDroppedTotal.Value() != dropped+1 {
+		t.Fatalf("expected dropped %d, actual %d", dropped+1, expHooksDroppedTotal.Value())
+	}
+	for len(hookQueue) > 0 {
+		<-hookQueue
+	}
+}
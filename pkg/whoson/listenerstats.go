@@ -0,0 +1,59 @@
+package whoson
+
+import (
+	"expvar"
+	"net"
+	"sync"
+)
+
+var (
+	// expListeners hold connection counters for each listen address.
+	expListeners   = new(expvar.Map)
+	expListenersMu sync.Mutex
+)
+
+// listenerStats hold connection counters of a listener.
+type listenerStats struct {
+	total   *expvar.Int
+	current *expvar.Int
+}
+
+// newListenerStats return counters of addr, labeled like "tcp://0.0.0.0:9876".
+func newListenerStats(addr net.Addr) *listenerStats {
+	if addr == nil {
+		return nil
+	}
+	key := listenerLabel(addr)
+	expListenersMu.Lock()
+	defer expListenersMu.Unlock()
+	m, ok := expListeners.Get(key).(*expvar.Map)
+	if !ok {
+		m = new(expvar.Map)
+		m.Set("ConnectsTotal", new(expvar.Int))
+		m.Set("ConnectsCurrent", new(expvar.Int))
+		expListeners.Set(key, m)
+	}
+	return &listenerStats{
+		total:   m.Get("ConnectsTotal").(*expvar.Int),
+		current: m.Get("ConnectsCurrent").(*expvar.Int),
+	}
+}
+
+func listenerLabel(addr net.Addr) string {
+	return addr.Network() + "://" + addr.String()
+}
+
+func (ls *listenerStats) connect() {
+	if ls == nil {
+		return
+	}
+	ls.total.Add(1)
+	ls.current.Add(1)
+}
+
+func (ls *listenerStats) disconnect() {
+	if ls == nil {
+		return
+	}
+	ls.current.Add(-1)
+}
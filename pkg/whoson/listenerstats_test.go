@@ -0,0 +1,58 @@
+package whoson
+
+import (
+	"expvar"
+	"net"
+	"testing"
+)
+
+func TestListenerStats(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	NewIDGenerator(uint(1))
+	l1, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	l2, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	done := make(chan error, 2)
+	go func() { done <- ServeTCP(l1) }()
+	go func() { done <- ServeTCP(l2) }()
+	defer func() {
+		l1.Close()
+		l2.Close()
+		<-done
+		<-done
+	}()
+
+	for _, addr := range []string{l1.Addr().String(), l1.Addr().String(), l2.Addr().String()} {
+		client, err := Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if _, err := client.Query("192.0.2.1"); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		client.Quit()
+	}
+
+	var tests = []struct {
+		addr     net.Addr
+		expected string
+	}{
+		{l1.Addr(), "2"},
+		{l2.Addr(), "1"},
+	}
+	for _, tt := range tests {
+		m, ok := expListeners.Get(listenerLabel(tt.addr)).(*expvar.Map)
+		if !ok {
+			t.Fatalf("stats of %v not found", tt.addr)
+		}
+		if actual := m.Get("ConnectsTotal").String(); actual != tt.expected {
+			t.Fatalf("%v expected %v, actual %v", tt.addr, tt.expected, actual)
+		}
+	}
+}
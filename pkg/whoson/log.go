@@ -9,6 +9,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// logLevel is level of Logger, changed by SetLogLevel.
+var logLevel = zap.NewAtomicLevel()
+
 // NewLogger return new zap.Logger struct pointer.
 func NewLogger(output, loglevel string) error {
 	if Logger == nil {
@@ -37,8 +40,7 @@ func InitLog(output, loglevel string) error {
 		LogWriter = f
 	}
 
-	var level zapcore.Level
-	if err := level.UnmarshalText([]byte(loglevel)); err != nil {
+	if err := SetLogLevel(loglevel); err != nil {
 		return err
 	}
 
@@ -55,7 +57,7 @@ func InitLog(output, loglevel string) error {
 		zapcore.NewCore(
 			encoder,
 			zapcore.Lock(writeSyncer),
-			level,
+			logLevel,
 		),
 		zap.ErrorOutput(writeSyncer),
 	)
@@ -63,6 +65,16 @@ func InitLog(output, loglevel string) error {
 	return nil
 }
 
+// SetLogLevel change level of Logger.
+func SetLogLevel(loglevel string) error {
+	level, err := zapcore.ParseLevel(loglevel)
+	if err != nil {
+		return err
+	}
+	logLevel.SetLevel(level)
+	return nil
+}
+
 func switchLogger(status string) (func(string, ...zapcore.Field), error) {
 	var logger func(string, ...zapcore.Field)
 
@@ -103,17 +115,17 @@ func Log(status, msg string, ses *Session, err error) {
 	if ses != nil && ses.id != 0 {
 		id = zap.Uint64("id", ses.id)
 		if ses.protocol == pTCP {
-			protocol = zap.String("protocol", "tcp")
+			protocol = zap.String("protocol", ses.conn.LocalAddr().Network())
 			remote = zap.String("remote", ses.conn.RemoteAddr().String())
 		} else if ses.protocol == pUDP {
-			protocol = zap.String("protocol", "udp")
+			protocol = zap.String("protocol", ses.pconn.LocalAddr().Network())
 			remote = zap.String("remote", ses.remoteAddr.String())
 		}
 		if method[ses.cmdMethod] != "" {
 			cmdMethod = zap.String("cmd", method[ses.cmdMethod])
 		}
 		if ses.cmdIP != nil {
-			cmdIP = zap.String("cmdip", ses.cmdIP.String())
+			cmdIP = zap.String("cmdip", ses.cmdKey())
 		}
 		if ses.cmdArgs != "" {
 			cmdArgs = zap.String("cmdargs", ses.cmdArgs)
@@ -0,0 +1,137 @@
+package whoson
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "gowhoson"
+
+	// protocol labels of requests not served by whoson sessions.
+	protocolHTTP = "http"
+	protocolGRPC = "grpc"
+
+	// result labels of requests, denied by ACL, rate limits or authentication.
+	resultAllowed = "allowed"
+	resultDenied  = "denied"
+)
+
+var (
+	// metricsRegistry hold Prometheus collectors served by NewMetricsHandler.
+	metricsRegistry = prometheus.NewRegistry()
+
+	promRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of requests by protocol, method and result.",
+	}, []string{"protocol", "method", "result"})
+	promRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests by protocol, method and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 14),
+	}, []string{"protocol", "method", "result"})
+	promExpiredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "expired_total",
+		Help:      "Number of records deleted by expiration.",
+	})
+	promSyncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "sync_total",
+		Help:      "Number of sync requests to SyncRemote peers by result.",
+	}, []string{"peer", "result"})
+	promStoreRecords = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "store_records",
+		Help:      "Number of records in the store.",
+	}, func() float64 {
+		if MainStore == nil {
+			return 0
+		}
+		return float64(MainStore.Count())
+	})
+
+	// udpQueues hold queues of running UDP and unixgram servers.
+	udpQueues = &udpQueueCollector{
+		queues: make(map[string]chan interface{}),
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "udp_queue_length"),
+			"Number of packets waiting for UDP workers by listener.",
+			[]string{"listener"}, nil,
+		),
+	}
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		promRequestsTotal,
+		promRequestDuration,
+		promExpiredTotal,
+		promSyncTotal,
+		promStoreRecords,
+		udpQueues,
+	)
+}
+
+// NewMetricsHandler return http.Handler of Prometheus metrics.
+func NewMetricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// observeRequest count the request of m with result, and its latency from start.
+func observeRequest(protocol string, m MethodType, start time.Time, result string) {
+	name := method[m]
+	promRequestsTotal.WithLabelValues(protocol, name, result).Inc()
+	promRequestDuration.WithLabelValues(protocol, name, result).Observe(time.Since(start).Seconds())
+}
+
+// observeSync count the result of sync request to peer.
+func observeSync(peer string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	promSyncTotal.WithLabelValues(peer, result).Inc()
+}
+
+// udpQueueCollector collect queue length of each UDP server when scraped.
+type udpQueueCollector struct {
+	mu     sync.Mutex
+	queues map[string]chan interface{}
+	desc   *prometheus.Desc
+}
+
+func (c *udpQueueCollector) add(label string, queue chan interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queues[label] = queue
+}
+
+func (c *udpQueueCollector) remove(label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.queues, label)
+}
+
+// Describe implements prometheus.Collector.
+func (c *udpQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *udpQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for label, queue := range c.queues {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(len(queue)), label)
+	}
+}
@@ -0,0 +1,122 @@
+package whoson
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveRequest(t *testing.T) {
+	counter := promRequestsTotal.WithLabelValues(protocolGRPC, "REFRESH", resultAllowed)
+	before := testutil.ToFloat64(counter)
+	observeRequest(protocolGRPC, mRefresh, time.Now().Add(-time.Millisecond), resultAllowed)
+	if n := testutil.ToFloat64(counter) - before; n != 1 {
+		t.Fatalf("expected 1 request, actual %v", n)
+	}
+	if n := testutil.CollectAndCount(promRequestDuration, "gowhoson_request_duration_seconds"); n == 0 {
+		t.Fatalf("request duration should be collected")
+	}
+}
+
+func TestObserveRequest_Denied(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	err := SetServerConfig(&ServerConfig{ACL: []ACLRule{{Network: "192.0.2.0/24", Methods: []string{"ALL"}}}})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- ServeTCP(l) }()
+	defer func() {
+		l.Close()
+		<-done
+	}()
+	ts := httptest.NewServer(NewAPIHandler())
+	defer ts.Close()
+
+	tcp := promRequestsTotal.WithLabelValues("tcp", "QUERY", resultDenied)
+	api := promRequestsTotal.WithLabelValues(protocolHTTP, "QUERY", resultDenied)
+	beforeTCP, beforeAPI := testutil.ToFloat64(tcp), testutil.ToFloat64(api)
+
+	client, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer client.Close()
+	if r, err := client.Query("198.18.8.5"); err != nil || r.String() != "*access denied" {
+		t.Fatalf("expected access denied, actual %v %v", r, err)
+	}
+	if status := apiRequest(t, ts, "GET", "/v1/query?ip=198.18.8.5", "", "", &APIError{}); status != 403 {
+		t.Fatalf("expected 403, actual %d", status)
+	}
+	if n := testutil.ToFloat64(tcp) - beforeTCP; n != 1 {
+		t.Fatalf("expected 1 denied tcp request, actual %v", n)
+	}
+	if n := testutil.ToFloat64(api) - beforeAPI; n != 1 {
+		t.Fatalf("expected 1 denied http request, actual %v", n)
+	}
+}
+
+func TestObserveSync(t *testing.T) {
+	peer := "198.18.8.1:9877"
+	success := promSyncTotal.WithLabelValues(peer, "success")
+	failure := promSyncTotal.WithLabelValues(peer, "failure")
+	beforeSuccess, beforeFailure := testutil.ToFloat64(success), testutil.ToFloat64(failure)
+	observeSync(peer, nil)
+	observeSync(peer, errors.New("unavailable"))
+	observeSync(peer, errors.New("unavailable"))
+	if n := testutil.ToFloat64(success) - beforeSuccess; n != 1 {
+		t.Fatalf("expected 1 success, actual %v", n)
+	}
+	if n := testutil.ToFloat64(failure) - beforeFailure; n != 2 {
+		t.Fatalf("expected 2 failures, actual %v", n)
+	}
+}
+
+func TestExpiredTotal(t *testing.T) {
+	NewLogger("discard", "error")
+	store := NewMemStore()
+	store.SyncSet("198.18.8.2", &StoreData{IP: net.ParseIP("198.18.8.2"), Expire: time.Now().Add(-time.Second)})
+	store.SyncSet("198.18.8.3", &StoreData{IP: net.ParseIP("198.18.8.3"), Expire: time.Now().Add(time.Minute)})
+	before := testutil.ToFloat64(promExpiredTotal)
+	deleteExpireData(context.Background(), store)
+	if n := testutil.ToFloat64(promExpiredTotal) - before; n != 1 {
+		t.Fatalf("expected 1 expiration, actual %v", n)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	NewMainStore()
+	queue := make(chan interface{}, 4)
+	queue <- nil
+	udpQueues.add("udp://198.18.8.4:9876", queue)
+	defer udpQueues.remove("udp://198.18.8.4:9876")
+
+	rec := httptest.NewRecorder()
+	NewMetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	b, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	for _, s := range []string{
+		`gowhoson_udp_queue_length{listener="udp://198.18.8.4:9876"} 1`,
+		"gowhoson_store_records ",
+		"go_goroutines ",
+	} {
+		if !strings.Contains(string(b), s) {
+			t.Fatalf("%q not found in metrics", s)
+		}
+	}
+}
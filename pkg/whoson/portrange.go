@@ -0,0 +1,118 @@
+package whoson
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// parseKey parse IP address or CIDR prefix, or IP address with port range
+// such as "192.0.2.1:1024-2047" and "[2001:db8::1]:1024-2047".
+func parseKey(s string) (*StoreData, error) {
+	ip, prefixLen, err := parseAddr(s)
+	if err == nil {
+		return &StoreData{IP: ip, PrefixLen: prefixLen}, nil
+	}
+	host, ports, serr := net.SplitHostPort(s)
+	if serr != nil {
+		return nil, err
+	}
+	if ip = net.ParseIP(host); ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+	from, to, err := parsePortRange(ports)
+	if err != nil {
+		return nil, err
+	}
+	return &StoreData{IP: ip, PortFrom: from, PortTo: to}, nil
+}
+
+// parsePortRange parse "<port>" or "<from>-<to>".
+func parsePortRange(s string) (from int, to int, err error) {
+	lo, hi, found := strings.Cut(s, "-")
+	from, err = strconv.Atoi(lo)
+	if err != nil {
+		return 0, 0, errors.New("invalid port range")
+	}
+	to = from
+	if found {
+		if to, err = strconv.Atoi(hi); err != nil {
+			return 0, 0, errors.New("invalid port range")
+		}
+	}
+	if from < 1 || to > 65535 || from > to {
+		return 0, 0, errors.New("invalid port range")
+	}
+	return from, to, nil
+}
+
+// portKey return store key of k with port range, k is returned if from is zero.
+func portKey(k string, from int, to int) string {
+	if from == 0 {
+		return k
+	}
+	ports := strconv.Itoa(from)
+	if to != from {
+		ports += "-" + strconv.Itoa(to)
+	}
+	return net.JoinHostPort(k, ports)
+}
+
+// portIndex hold port range keys of each IP address.
+type portIndex struct {
+	mu   sync.RWMutex
+	keys map[string]string
+	ips  map[string]map[string]struct{}
+}
+
+func newPortIndex() *portIndex {
+	return &portIndex{
+		keys: make(map[string]string),
+		ips:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (pi *portIndex) add(k string, sd *StoreData) {
+	if sd.PortFrom == 0 {
+		return
+	}
+	ip := sd.IP.String()
+
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	keys, ok := pi.ips[ip]
+	if !ok {
+		keys = make(map[string]struct{})
+		pi.ips[ip] = keys
+	}
+	keys[k] = struct{}{}
+	pi.keys[k] = ip
+}
+
+func (pi *portIndex) remove(k string) {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	ip, ok := pi.keys[k]
+	if !ok {
+		return
+	}
+	delete(pi.keys, k)
+	delete(pi.ips[ip], k)
+	if len(pi.ips[ip]) == 0 {
+		delete(pi.ips, ip)
+	}
+}
+
+// lookupKeys return port range keys of ip.
+func (pi *portIndex) lookupKeys(ip net.IP) []string {
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+	var keys []string
+	for k := range pi.ips[ip.String()] {
+		keys = append(keys, k)
+	}
+	return keys
+}
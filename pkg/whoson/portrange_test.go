@@ -0,0 +1,81 @@
+package whoson
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	var tests = []struct {
+		key      string
+		expected string
+		hasError bool
+	}{
+		{"192.0.2.1", "192.0.2.1", false},
+		{"192.0.2.0/24", "192.0.2.0/24", false},
+		{"192.0.2.1:1024-2047", "192.0.2.1:1024-2047", false},
+		{"192.0.2.1:1500", "192.0.2.1:1500", false},
+		{"192.0.2.1:1500-1500", "192.0.2.1:1500", false},
+		{"[2001:db8::1]:1024-2047", "[2001:db8::1]:1024-2047", false},
+		{"2001:db8::1", "2001:db8::1", false},
+		{"192.0.2.1:2047-1024", "", true},
+		{"192.0.2.1:0-10", "", true},
+		{"192.0.2.1:1024-65536", "", true},
+		{"192.0.2.1:abc", "", true},
+		{"192.0.2.0/24:1024", "", true},
+		{"host:1024", "", true},
+	}
+	for _, tt := range tests {
+		sd, err := parseKey(tt.key)
+		if tt.hasError {
+			if err == nil {
+				t.Fatalf("expected error, key %v", tt.key)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Error %v, key %v", err, tt.key)
+		}
+		if actual := sd.Key(); tt.expected != actual {
+			t.Fatalf("expected %v, actual %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestMemStore_LookupPort(t *testing.T) {
+	ms := NewMemStore().(MemStore)
+	for _, key := range []string{"192.0.2.1:1024-2047", "192.0.2.1:1500-1599", "192.0.2.1:2048-3071", "192.0.2.0/24"} {
+		sd, err := parseKey(key)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		sd.Data = key
+		sd.Expire = newStoreData("").Expire
+		ms.Set(sd.Key(), sd)
+	}
+
+	var tests = []struct {
+		port     int
+		expected string
+	}{
+		{1024, "192.0.2.1:1024-2047"},
+		{1550, "192.0.2.1:1500-1599"},
+		{3071, "192.0.2.1:2048-3071"},
+		{4000, "192.0.2.0/24"},
+		{0, "192.0.2.0/24"},
+	}
+	for _, tt := range tests {
+		sd, err := ms.Lookup(net.ParseIP("192.0.2.1"), tt.port)
+		if err != nil {
+			t.Fatalf("Error %v, port %v", err, tt.port)
+		}
+		if tt.expected != sd.Data {
+			t.Fatalf("expected %v, actual %v", tt.expected, sd.Data)
+		}
+	}
+
+	ms.Del("192.0.2.1:1500-1599")
+	if sd, err := ms.Lookup(net.ParseIP("192.0.2.1"), 1550); err != nil || sd.Data != "192.0.2.1:1024-2047" {
+		t.Fatalf("expected 192.0.2.1:1024-2047 after delete, actual %v %v", sd, err)
+	}
+}
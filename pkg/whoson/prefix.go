@@ -0,0 +1,102 @@
+package whoson
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// parseAddr parse IP address or CIDR prefix, prefixLen is zero for IP address.
+func parseAddr(s string) (ip net.IP, prefixLen int, err error) {
+	if !strings.Contains(s, "/") {
+		if ip = net.ParseIP(s); ip == nil {
+			return nil, 0, errors.New("invalid IP address")
+		}
+		return ip, 0, nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	ones, size := network.Mask.Size()
+	if ones == 0 {
+		return nil, 0, errors.New("prefix length must be positive")
+	}
+	if ones == size {
+		return network.IP, 0, nil
+	}
+	return network.IP, ones, nil
+}
+
+// storeKey return store key of IP address or CIDR prefix.
+func storeKey(ip net.IP, prefixLen int) string {
+	if prefixLen == 0 {
+		return ip.String()
+	}
+	return fmt.Sprintf("%s/%d", ip.String(), prefixLen)
+}
+
+func ipFamily(ip net.IP) (family int, bits int) {
+	if ip.To4() != nil {
+		return 0, 8 * net.IPv4len
+	}
+	return 1, 8 * net.IPv6len
+}
+
+// prefixIndex hold prefix lengths in use, for longest prefix match.
+type prefixIndex struct {
+	mu     sync.RWMutex
+	keys   map[string][2]int
+	counts [2][8*net.IPv6len + 1]int
+}
+
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{
+		keys: make(map[string][2]int),
+	}
+}
+
+func (pi *prefixIndex) add(k string, sd *StoreData) {
+	if sd.PrefixLen == 0 {
+		return
+	}
+	family, _ := ipFamily(sd.IP)
+
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	if _, ok := pi.keys[k]; ok {
+		return
+	}
+	pi.keys[k] = [2]int{family, sd.PrefixLen}
+	pi.counts[family][sd.PrefixLen]++
+}
+
+func (pi *prefixIndex) remove(k string) {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	if v, ok := pi.keys[k]; ok {
+		pi.counts[v[0]][v[1]]--
+		delete(pi.keys, k)
+	}
+}
+
+// lookupKeys return prefix keys containing ip, longest prefix first.
+func (pi *prefixIndex) lookupKeys(ip net.IP) []string {
+	family, bits := ipFamily(ip)
+	if family == 0 {
+		ip = ip.To4()
+	}
+
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+	var keys []string
+	for l := bits - 1; l > 0; l-- {
+		if pi.counts[family][l] > 0 {
+			keys = append(keys, storeKey(ip.Mask(net.CIDRMask(l, bits)), l))
+		}
+	}
+	return keys
+}
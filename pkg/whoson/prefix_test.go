@@ -0,0 +1,85 @@
+package whoson
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseAddr(t *testing.T) {
+	var tests = []struct {
+		addr      string
+		key       string
+		prefixLen int
+		hasError  bool
+	}{
+		{"10.0.0.1", "10.0.0.1", 0, false},
+		{"10.0.0.5/24", "10.0.0.0/24", 24, false},
+		{"10.0.0.5/32", "10.0.0.5", 0, false},
+		{"2001:db8:1::1/64", "2001:db8:1::/64", 64, false},
+		{"2001:db8:1::1", "2001:db8:1::1", 0, false},
+		{"10.0.0.0/0", "", 0, true},
+		{"10.0.0.0/33", "", 0, true},
+		{"10.0.0", "", 0, true},
+	}
+	for _, tt := range tests {
+		ip, prefixLen, err := parseAddr(tt.addr)
+		if tt.hasError {
+			if err == nil {
+				t.Fatalf("expected error, addr %q", tt.addr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if key := storeKey(ip, prefixLen); tt.key != key || tt.prefixLen != prefixLen {
+			t.Fatalf("expected %v %v, actual %v %v", tt.key, tt.prefixLen, key, prefixLen)
+		}
+	}
+}
+
+func TestMemStore_Lookup(t *testing.T) {
+	ms := NewMemStore().(MemStore)
+	for _, addr := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", "2001:db8::/32", "2001:db8:1::/64"} {
+		ip, prefixLen, err := parseAddr(addr)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		sd := newStoreData(addr)
+		sd.IP = ip
+		sd.PrefixLen = prefixLen
+		ms.Set(sd.Key(), sd)
+	}
+
+	var tests = []struct {
+		ip       string
+		expected string
+	}{
+		{"10.1.2.3", "10.1.2.3"},
+		{"10.1.2.4", "10.1.0.0/16"},
+		{"10.2.0.1", "10.0.0.0/8"},
+		{"2001:db8:1::abcd", "2001:db8:1::/64"},
+		{"2001:db8:2::1", "2001:db8::/32"},
+		{"11.0.0.1", ""},
+	}
+	for _, tt := range tests {
+		sd, err := ms.Lookup(net.ParseIP(tt.ip), 0)
+		if tt.expected == "" {
+			if err == nil {
+				t.Fatalf("expected not found, ip %v, actual %v", tt.ip, sd.Data)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Error %v, ip %v", err, tt.ip)
+		}
+		if tt.expected != sd.Data {
+			t.Fatalf("expected %v, actual %v", tt.expected, sd.Data)
+		}
+	}
+
+	ms.Del("10.1.0.0/16")
+	if sd, err := ms.Lookup(net.ParseIP("10.1.2.4"), 0); err != nil || sd.Data != "10.0.0.0/8" {
+		t.Fatalf("expected 10.0.0.0/8 after delete, actual %v %v", sd, err)
+	}
+}
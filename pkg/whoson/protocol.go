@@ -1,15 +1,20 @@
 package whoson
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/textproto"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Session hold information for whoson session.
@@ -17,8 +22,8 @@ type Session struct {
 	protocol ProtocolType
 	id       uint64
 
-	udpconn    *net.UDPConn
-	remoteAddr *net.UDPAddr
+	pconn      net.PacketConn
+	remoteAddr net.Addr
 	b          *Buffer
 
 	tcpserver *TCPServer
@@ -26,13 +31,18 @@ type Session struct {
 	tp        *textproto.Conn
 	tpid      uint
 
-	cmdMethod MethodType
-	cmdIP     net.IP
-	cmdArgs   string
+	cmdMethod    MethodType
+	cmdIP        net.IP
+	cmdPrefixLen int
+	cmdPortFrom  int
+	cmdPortTo    int
+	cmdArgs      string
+	cmdTTL       time.Duration
+	cmdAuth      *authParams
 }
 
-// NewSessionUDP return new Session struct pointer for UDP.
-func NewSessionUDP(c *net.UDPConn, r *net.UDPAddr, b *Buffer) (*Session, error) {
+// NewSessionUDP return new Session struct pointer for UDP or unixgram.
+func NewSessionUDP(c net.PacketConn, r net.Addr, b *Buffer) (*Session, error) {
 	id, err := IDGenerator.NextID()
 	if err != nil {
 		return nil, err
@@ -41,13 +51,13 @@ func NewSessionUDP(c *net.UDPConn, r *net.UDPAddr, b *Buffer) (*Session, error)
 	return &Session{
 		protocol:   pUDP,
 		id:         id,
-		udpconn:    c,
+		pconn:      c,
 		remoteAddr: r,
 		b:          b,
 	}, nil
 }
 
-// NewSessionTCP return new Session struct pointer for TCP.
+// NewSessionTCP return new Session struct pointer for TCP or unix.
 func NewSessionTCP(s *TCPServer, c net.Conn) (*Session, error) {
 	id, err := IDGenerator.NextID()
 	if err != nil {
@@ -67,6 +77,19 @@ func (ses *Session) setTpID() {
 	ses.tpid = ses.tp.Next()
 }
 
+// network return network of the listener like "tcp" or "unixgram", used as protocol of metrics.
+func (ses *Session) network() string {
+	switch {
+	case ses.conn != nil:
+		return ses.conn.LocalAddr().Network()
+	case ses.pconn != nil:
+		return ses.pconn.LocalAddr().Network()
+	case ses.protocol == pTCP:
+		return "tcp"
+	}
+	return "udp"
+}
+
 func (ses *Session) methodType(m string) MethodType {
 	if v, ok := methodFromString[m]; ok {
 		return v
@@ -74,27 +97,42 @@ func (ses *Session) methodType(m string) MethodType {
 	return mUnkownMethod
 }
 
-func (ses *Session) parseCmd(line string) error {
+func splitCmd(line string) []string {
 	var cmd []string
-
-	if strings.TrimSpace(line) == "" {
-		return errors.New("command parse error")
-	}
-
 	w := strings.Split(line, " ")
 	for i := range w {
 		if w[i] != "" {
 			cmd = append(cmd, strings.TrimSpace(w[i]))
 		}
 	}
+	return cmd
+}
+
+func (ses *Session) parseCmd(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return errors.New("command parse error")
+	}
+	cmd := splitCmd(line)
 
 	ses.cmdMethod = ses.methodType(strings.ToUpper(cmd[0]))
 	switch ses.cmdMethod {
-	case mLogin, mLogout, mQuery:
-		if ses.cmdIP = net.ParseIP(cmd[1]); ses.cmdIP == nil {
+	case mLogin, mLogout, mQuery, mRefresh:
+		if len(cmd) < 2 {
+			return errors.New("command parse error")
+		}
+		if err := ses.parseAddr(cmd[1]); err != nil {
+			return err
+		}
+		args, err := ses.parseOptions(cmd)
+		if err != nil {
+			return err
+		}
+		ses.cmdArgs = strings.Join(args, " ")
+	case mLookup:
+		if len(cmd) != 2 {
 			return errors.New("command parse error")
 		}
-		ses.cmdArgs = strings.Join(cmd[2:], " ")
+		ses.cmdArgs = cmd[1]
 	case mQuit:
 		ses.cmdArgs = strings.Join(cmd[1:], " ")
 	default:
@@ -103,8 +141,114 @@ func (ses *Session) parseCmd(line string) error {
 	return nil
 }
 
+// parseAddr parse IP address, CIDR prefix or IP address with port range,
+// QUERY accepts IP address with single port but not CIDR prefix.
+func (ses *Session) parseAddr(s string) error {
+	sd, err := parseKey(s)
+	if err != nil {
+		return errors.New("command parse error")
+	}
+	if ses.cmdMethod == mQuery && (sd.PrefixLen > 0 || sd.PortFrom != sd.PortTo) {
+		return errors.New("command parse error")
+	}
+	ses.cmdIP = sd.IP
+	ses.cmdPrefixLen = sd.PrefixLen
+	ses.cmdPortFrom = sd.PortFrom
+	ses.cmdPortTo = sd.PortTo
+	return nil
+}
+
+// cmdKey return store key of command IP address, CIDR prefix or port range.
+func (ses *Session) cmdKey() string {
+	return portKey(storeKey(ses.cmdIP, ses.cmdPrefixLen), ses.cmdPortFrom, ses.cmdPortTo)
+}
+
+// parseOptions take trailing options of cmd, and return the rest of arguments.
+// A signed command ends with "ts=<unix> nonce=<hex> keyid=<id> sig=<hex>",
+// which is data of command unless AuthKeys or AuthRequired is set.
+func (ses *Session) parseOptions(cmd []string) ([]string, error) {
+	args := cmd[2:]
+	if ses.cmdMethod == mQuery {
+		return args, nil
+	}
+
+	config := getServerConfig()
+	if n := len(args); config.authEnabled() && n > 0 && strings.HasPrefix(strings.ToLower(args[n-1]), authSignaturePrefix) {
+		ses.cmdAuth = &authParams{
+			signature: args[n-1][len(authSignaturePrefix):],
+			signed:    authCanonical(cmd[:len(cmd)-1]),
+		}
+		for n--; n > 0 && ses.cmdAuth.set(args[n-1]); n-- {
+		}
+		args = args[:n]
+	}
+
+	if ses.cmdMethod == mLogout {
+		return args, nil
+	}
+	return ses.parseTTL(config, args)
+}
+
+// parseTTL take "ttl=<seconds>" from the last of args, and return the rest.
+// Invalid ttl is data of command unless TTL options of config are set.
+func (ses *Session) parseTTL(config *ServerConfig, args []string) ([]string, error) {
+	n := len(args)
+	if n == 0 || !strings.HasPrefix(strings.ToLower(args[n-1]), ttlArgPrefix) {
+		return args, nil
+	}
+	sec, err := strconv.Atoi(args[n-1][len(ttlArgPrefix):])
+	if err != nil || sec <= 0 {
+		if config.ttlEnabled() {
+			return nil, errors.New("command parse error")
+		}
+		return args, nil
+	}
+	ses.cmdTTL = time.Duration(sec) * time.Second
+	return args[:n-1], nil
+}
+
+// authorize check whether the parsed command is allowed to run.
+func (ses *Session) authorize() error {
+	if err := ses.checkRateLimit(); err != nil {
+		return err
+	}
+	if err := ses.checkACL(); err != nil {
+		return err
+	}
+	return ses.authenticate()
+}
+
+// remoteIP return IP address of the session client.
+func (ses *Session) remoteIP() net.IP {
+	switch ses.protocol {
+	case pTCP:
+		if addr, ok := ses.conn.RemoteAddr().(*net.TCPAddr); ok {
+			return addr.IP
+		}
+	case pUDP:
+		if addr, ok := ses.remoteAddr.(*net.UDPAddr); ok {
+			return addr.IP
+		}
+	}
+	return nil
+}
+
+// isLocal return true when the session client is on unix domain socket.
+func (ses *Session) isLocal() bool {
+	var addr net.Addr
+	switch ses.protocol {
+	case pTCP:
+		addr = ses.conn.RemoteAddr()
+	case pUDP:
+		addr = ses.remoteAddr
+	}
+	_, ok := addr.(*net.UnixAddr)
+	return ok
+}
+
 func (ses *Session) readLine() (string, error) {
 	ses.tp.StartRequest(ses.tpid)
+	defer ses.tp.EndRequest(ses.tpid)
 	l1, err := ses.tp.ReadLine()
 	if err != nil {
 		return "", err
@@ -114,7 +258,6 @@ func (ses *Session) readLine() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	ses.tp.EndRequest(ses.tpid)
 
 	if l1 != "" && l2 == "" {
 		return l1, nil
@@ -130,7 +273,7 @@ func (ses *Session) sendLine(str string) error {
 		ses.tp.EndResponse(ses.tpid)
 	} else {
 		b := []byte(str + charCRLF + charCRLF)
-		_, err = ses.udpconn.WriteToUDP(b, ses.remoteAddr)
+		_, err = ses.pconn.WriteTo(b, ses.remoteAddr)
 	}
 	return err
 }
@@ -150,46 +293,75 @@ func (ses *Session) sendResponseBadRequest(str string) error {
 func (ses *Session) resetCmd() {
 	ses.cmdMethod = mUnkownMethod
 	ses.cmdIP = nil
+	ses.cmdPrefixLen = 0
+	ses.cmdPortFrom = 0
+	ses.cmdPortTo = 0
 	ses.cmdArgs = ""
+	ses.cmdTTL = 0
+	ses.cmdAuth = nil
 }
 
 func (ses *Session) startHandler() bool {
 	defer ses.resetCmd()
 	var err error
 
+	var line string
 	if ses.protocol == pTCP {
 		ses.setTpID()
-		line, err := ses.readLine()
-		if !ses.tcpErrorHandling(err) {
-			return false
-		}
-		err = ses.parseCmd(line)
+		line, err = ses.readLine()
 		if err != nil {
-			Log("debug", "StartHandler", ses, err)
-			ses.sendResponseBadRequest(err.Error())
-			return true
+			return ses.tcpErrorHandling(err)
 		}
 	} else {
-		err = ses.parseCmd(string(ses.b.buf[:ses.b.count]))
-		if err != nil {
-			Log("debug", "StartHandler", ses, err)
-			ses.sendResponseBadRequest(err.Error())
-			return true
-		}
+		line = string(ses.b.buf[:ses.b.count])
+	}
+	ctx, span := startSpan(context.Background(), "Session.startHandler",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("network.transport", ses.network())))
+	defer span.End()
+	err = ses.parseCmd(line)
+	if err != nil {
+		Log("debug", "StartHandler", ses, err)
+		spanError(span, err)
+		ses.sendResponseBadRequest(err.Error())
+		return true
+	}
+	span.SetAttributes(attribute.String("whoson.method", method[ses.cmdMethod]))
+	start, result := time.Now(), resultAllowed
+	defer func() {
+		observeRequest(ses.network(), ses.cmdMethod, start, result)
+	}()
+	err = ses.authorize()
+	if err == errDropped {
+		result = resultDenied
+		return true
+	} else if err != nil {
+		result = resultDenied
+		spanError(span, err)
+		ses.sendResponseBadRequest(err.Error())
+		return true
 	}
 
 	switch ses.cmdMethod {
 	case mLogin:
 		expCommandLoginTotal.Add(1)
-		ses.methodLogin()
+		ses.methodLogin(ctx)
 		Log("debug", "SessionHandler", ses, err)
 	case mLogout:
 		expCommandLogoutTotal.Add(1)
-		ses.methodLogout()
+		ses.methodLogout(ctx)
 		Log("debug", "SessionHandler", ses, err)
 	case mQuery:
 		expCommandQueryTotal.Add(1)
-		ses.methodQuery()
+		ses.methodQuery(ctx)
+		Log("debug", "SessionHandler", ses, err)
+	case mRefresh:
+		expCommandRefreshTotal.Add(1)
+		ses.methodRefresh(ctx)
+		Log("debug", "SessionHandler", ses, err)
+	case mLookup:
+		expCommandLookupTotal.Add(1)
+		ses.methodLookup()
 		Log("debug", "SessionHandler", ses, err)
 	case mQuit:
 		expCommandQuitTotal.Add(1)
@@ -219,6 +391,10 @@ func (ses *Session) tcpErrorHandling(err error) bool {
 			Log("debug", "StartHandler:EOF", ses, err)
 			return false
 		}
+		if errors.Is(err, net.ErrClosed) {
+			Log("debug", "StartHandler:Closed", ses, err)
+			return false
+		}
 		expErrorsTotal.Add(1)
 		Log("error", "StartHandler:Error", ses, err)
 		ses.sendResponseBadRequest(err.Error())
@@ -227,27 +403,70 @@ func (ses *Session) tcpErrorHandling(err error) bool {
 	return true
 }
 
-func (ses *Session) methodLogin() {
-	sd := &StoreData{
-		Expire: time.Now().Add(StoreDataExpire),
-		IP:     ses.cmdIP,
-		Data:   ses.cmdArgs,
+// loginData store sd with ttl limited by ServerConfig, requested is zero for default ttl.
+func loginData(ctx context.Context, sd *StoreData, requested time.Duration) {
+	ttl := getServerConfig().LoginTTL(requested)
+	sd.Expire = time.Now().Add(ttl)
+	sd.TTL = ttl
+	sd.Created = time.Now()
+	storeSet(ctx, MainStore, sd.Key(), sd)
+	storeEvent(hLogin, eventLocal, sd)
+}
+
+// logoutData delete data of key, and return deleted data, ok is false when no data is found.
+func logoutData(ctx context.Context, key string) (sd *StoreData, ok bool) {
+	sd, _ = MainStore.Get(key)
+	if !storeDel(ctx, MainStore, key) {
+		return nil, false
 	}
-	MainStore.Set(sd.Key(), sd)
+	storeEvent(hLogout, eventLocal, sd)
+	return sd, true
+}
+
+// queryData return data of ip and port, and slide its expire time.
+func queryData(ctx context.Context, ip net.IP, port int) (*StoreData, error) {
+	sd, err := storeLookup(MainStore, ip, port)
+	if err != nil {
+		return nil, err
+	}
+	slideExpire(ctx, sd)
+	return sd, nil
+}
+
+// refreshData update expire time of key, ttl is limited by ServerConfig and zero keeps ttl of data.
+func refreshData(ctx context.Context, key string, ttl time.Duration) (*StoreData, error) {
+	if ttl > 0 {
+		ttl = getServerConfig().LoginTTL(ttl)
+	}
+	sd, err := storeRefresh(ctx, MainStore, key, ttl)
+	if err != nil {
+		return nil, err
+	}
+	storeEvent(hRefresh, eventLocal, sd)
+	return sd, nil
+}
+
+func (ses *Session) methodLogin(ctx context.Context) {
+	loginData(ctx, &StoreData{
+		IP:        ses.cmdIP,
+		PrefixLen: ses.cmdPrefixLen,
+		PortFrom:  ses.cmdPortFrom,
+		PortTo:    ses.cmdPortTo,
+		Data:      ses.cmdArgs,
+	}, ses.cmdTTL)
 	ses.sendResponsePositive("LOGIN OK")
 }
 
-func (ses *Session) methodLogout() {
-	ok := MainStore.Del(ses.cmdIP.String())
-	if ok {
+func (ses *Session) methodLogout(ctx context.Context) {
+	if _, ok := logoutData(ctx, ses.cmdKey()); ok {
 		ses.sendResponsePositive("LOGOUT record deleted")
 	} else {
 		ses.sendResponsePositive("LOGOUT no such record, nothing done")
 	}
 }
 
-func (ses *Session) methodQuery() {
-	sd, err := MainStore.Get(ses.cmdIP.String())
+func (ses *Session) methodQuery(ctx context.Context) {
+	sd, err := queryData(ctx, ses.cmdIP, ses.cmdPortFrom)
 	if err != nil {
 		ses.sendResponseNegative("Not Logged in")
 	} else {
@@ -255,6 +474,40 @@ func (ses *Session) methodQuery() {
 	}
 }
 
+func slideExpire(ctx context.Context, sd *StoreData) {
+	config := getServerConfig()
+	if !config.SlidingExpire {
+		return
+	}
+	if expire, ok := config.SlideExpire(sd, time.Now()); ok {
+		if sd, err := storeSetExpire(ctx, MainStore, sd.Key(), expire); err == nil {
+			storeEvent(hRefresh, eventLocal, sd)
+		}
+	}
+}
+
+func (ses *Session) methodRefresh(ctx context.Context) {
+	if _, err := refreshData(ctx, ses.cmdKey(), ses.cmdTTL); err != nil {
+		ses.sendResponseNegative("REFRESH no such record")
+	} else {
+		ses.sendResponsePositive("REFRESH OK")
+	}
+}
+
+func (ses *Session) methodLookup() {
+	sds := storeFindByUser(MainStore, ses.cmdArgs)
+	if len(sds) == 0 {
+		ses.sendResponseNegative("Not Logged in")
+		return
+	}
+	keys := make([]string, len(sds))
+	for i, sd := range sds {
+		keys[i] = sd.Key()
+	}
+	sort.Strings(keys)
+	ses.sendResponsePositive(strings.Join(keys, " "))
+}
+
 func (ses *Session) methodQuit() {
 	ses.sendResponsePositive("QUIT OK")
 	if ses.protocol == pTCP {
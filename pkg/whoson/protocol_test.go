@@ -4,6 +4,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewSessionUDP(t *testing.T) {
@@ -22,3 +23,55 @@ func TestNewSessionUDP(t *testing.T) {
 
 func TestNewSessionTCP(t *testing.T) {
 }
+
+func TestSession_parseCmdTTL(t *testing.T) {
+	defer SetServerConfig(nil)
+	noOptions := &ServerConfig{}
+	ttlOptions := &ServerConfig{TTLMax: 86400}
+	authOptions := &ServerConfig{AuthKeys: map[string]string{"k1": "secret"}}
+	var tests = []struct {
+		config   *ServerConfig
+		line     string
+		args     string
+		ttl      time.Duration
+		hasError bool
+	}{
+		{noOptions, "LOGIN 10.0.0.1 user01", "user01", 0, false},
+		{noOptions, "LOGIN 10.0.0.1 user01 ttl=3600", "user01", time.Hour, false},
+		{noOptions, "LOGIN 10.0.0.1 ttl=60", "", time.Minute, false},
+		{noOptions, "LOGIN 10.0.0.1 ttl=60 user01", "ttl=60 user01", 0, false},
+		{noOptions, "LOGIN 10.0.0.1 user01 TTL=30", "user01", 30 * time.Second, false},
+		{noOptions, "LOGIN 10.0.0.1 user01 ttl=abc", "user01 ttl=abc", 0, false},
+		{noOptions, "LOGIN 10.0.0.1 user01 ttl=0", "user01 ttl=0", 0, false},
+		{ttlOptions, "LOGIN 10.0.0.1 user01 ttl=abc", "", 0, true},
+		{ttlOptions, "LOGIN 10.0.0.1 user01 ttl=0", "", 0, true},
+		{noOptions, "LOGIN 10.0.0.1 user01 a=b c=d", "user01 a=b c=d", 0, false},
+		{noOptions, "LOGIN 10.0.0.1 user01 keyid=k1 sig=00", "user01 keyid=k1 sig=00", 0, false},
+		{noOptions, "LOGOUT 10.0.0.1 user01 sig=00", "user01 sig=00", 0, false},
+		{authOptions, "LOGIN 10.0.0.1 user01 keyid=k1", "user01 keyid=k1", 0, false},
+		{authOptions, "LOGIN 10.0.0.1 user01 keyid=k1 sig=00", "user01", 0, false},
+		{noOptions, "LOGIN", "", 0, true},
+		{noOptions, "LOOKUP user01", "user01", 0, false},
+		{noOptions, "LOOKUP", "", 0, true},
+		{noOptions, "LOOKUP user01 user02", "", 0, true},
+	}
+	for _, tt := range tests {
+		if err := SetServerConfig(tt.config); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		ses := &Session{}
+		err := ses.parseCmd(tt.line)
+		if tt.hasError {
+			if err == nil {
+				t.Fatalf("expected error, line %q", tt.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if tt.args != ses.cmdArgs || tt.ttl != ses.cmdTTL {
+			t.Fatalf("%q: expected %q %v, actual %q %v", tt.line, tt.args, tt.ttl, ses.cmdArgs, ses.cmdTTL)
+		}
+	}
+}
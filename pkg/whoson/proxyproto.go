@@ -0,0 +1,184 @@
+package whoson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	proxyV1Prefix  = "PROXY "
+	proxyV1MaxLen  = 107
+	proxyV2HdrLen  = 16
+	proxyV2Local   = 0x20
+	proxyV2Proxy   = 0x21
+	proxyV2TCP4    = 0x11
+	proxyV2TCP6    = 0x21
+	proxyV2Addr4   = 12
+	proxyV2Addr6   = 36
+	proxyUnknownV1 = "UNKNOWN"
+)
+
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// trustedProxies hold networks of proxies which send PROXY protocol header.
+type trustedProxies []*net.IPNet
+
+func newTrustedProxies(networks []string) (trustedProxies, error) {
+	var tp trustedProxies
+	for _, s := range networks {
+		network, err := parseNetwork(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "TrustedProxies %q", s)
+		}
+		tp = append(tp, network)
+	}
+	return tp, nil
+}
+
+func (tp trustedProxies) contains(ip net.IP) bool {
+	for _, network := range tp {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrustedProxy return true when addr is a trusted proxy.
+func isTrustedProxy(addr net.Addr) bool {
+	a, ok := addr.(*net.TCPAddr)
+	return ok && getServerConfig().proxies.contains(a.IP)
+}
+
+// proxyConn is net.Conn which RemoteAddr is client address of PROXY protocol header.
+type proxyConn struct {
+	net.Conn
+	r      *bufio.Reader
+	remote net.Addr
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// acceptProxy read PROXY protocol header when conn is from trusted proxy.
+func acceptProxy(conn net.Conn, timeout time.Duration) (net.Conn, error) {
+	if !isTrustedProxy(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	remote, err := readProxyHeader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "PROXY protocol")
+	}
+	if remote != nil && !allowSource(remote.(*net.TCPAddr).IP) {
+		return nil, errDropped
+	}
+	return &proxyConn{Conn: conn, r: r, remote: remote}, nil
+}
+
+// readProxyHeader read PROXY protocol v1 or v2 header, and return source
+// address of header, nil for LOCAL and UNKNOWN. Only bytes of the header
+// are waited for, so that a short v1 header is not blocked.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	switch b[0] {
+	case proxyV1Prefix[0]:
+		prefix, err := r.Peek(len(proxyV1Prefix))
+		if err != nil {
+			return nil, err
+		}
+		if string(prefix) == proxyV1Prefix {
+			return readProxyHeaderV1(r)
+		}
+	case proxyV2Signature[0]:
+		sig, err := r.Peek(len(proxyV2Signature))
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(sig, proxyV2Signature) {
+			return readProxyHeaderV2(r)
+		}
+	}
+	return nil, errors.New("header not found")
+}
+
+func readProxyHeaderV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyV1MaxLen {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte(charCRLF)) {
+		return nil, errors.New("v1 header too long")
+	}
+	f := strings.Fields(string(line))
+	if len(f) >= 2 && f[1] == proxyUnknownV1 {
+		return nil, nil
+	}
+	if len(f) != 6 || (f[1] != "TCP4" && f[1] != "TCP6") {
+		return nil, errors.New("v1 header parse error")
+	}
+	ip := net.ParseIP(f[2])
+	port, err := strconv.ParseUint(f[4], 10, 16)
+	if ip == nil || err != nil || (f[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, errors.New("v1 header parse error")
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readProxyHeaderV2(r *bufio.Reader) (net.Addr, error) {
+	hdr := make([]byte, proxyV2HdrLen)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint16(hdr[14:16]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	switch hdr[12] {
+	case proxyV2Local:
+		return nil, nil
+	case proxyV2Proxy:
+	default:
+		return nil, errors.New("v2 header unsupported version or command")
+	}
+	switch {
+	case hdr[13] == proxyV2TCP4 && len(body) >= proxyV2Addr4:
+		return &net.TCPAddr{IP: net.IP(body[0:4]), Port: int(binary.BigEndian.Uint16(body[8:10]))}, nil
+	case hdr[13] == proxyV2TCP6 && len(body) >= proxyV2Addr6:
+		return &net.TCPAddr{IP: net.IP(body[0:16]), Port: int(binary.BigEndian.Uint16(body[32:34]))}, nil
+	case hdr[13] == proxyV2TCP4 || hdr[13] == proxyV2TCP6:
+		return nil, errors.New("v2 header address too short")
+	}
+	// other families are accepted, and the proxy address is used.
+	return nil, nil
+}
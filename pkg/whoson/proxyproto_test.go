@@ -0,0 +1,130 @@
+package whoson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func proxyV2Header(cmd, fam byte, addr []byte) []byte {
+	b := append([]byte{}, proxyV2Signature...)
+	b = append(b, cmd, fam, 0, 0)
+	binary.BigEndian.PutUint16(b[14:16], uint16(len(addr)))
+	return append(b, addr...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	addr4 := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0x30, 0x39, 0x26, 0x94}
+	addr6 := make([]byte, proxyV2Addr6)
+	copy(addr6, net.ParseIP("2001:db8::1"))
+	copy(addr6[16:], net.ParseIP("2001:db8::2"))
+	binary.BigEndian.PutUint16(addr6[32:], 12345)
+
+	var tests = []struct {
+		header   []byte
+		expected string
+		hasError bool
+	}{
+		{[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345 9876\r\n"), "192.0.2.1:12345", false},
+		{[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 12345 9876\r\n"), "[2001:db8::1]:12345", false},
+		{[]byte("PROXY UNKNOWN\r\n"), "", false},
+		{[]byte("PROXY TCP4 2001:db8::1 2001:db8::2 12345 9876\r\n"), "", true},
+		{[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345\r\n"), "", true},
+		{[]byte("QUERY 192.0.2.1\r\n"), "", true},
+		{proxyV2Header(proxyV2Proxy, proxyV2TCP4, addr4), "192.0.2.1:12345", false},
+		{proxyV2Header(proxyV2Proxy, proxyV2TCP6, addr6), "[2001:db8::1]:12345", false},
+		{proxyV2Header(proxyV2Local, 0, nil), "", false},
+		{proxyV2Header(proxyV2Proxy, proxyV2TCP4, addr4[:4]), "", true},
+		{proxyV2Header(0x22, proxyV2TCP4, addr4), "", true},
+	}
+	for _, tt := range tests {
+		r := bufio.NewReader(bytes.NewReader(append(tt.header, "QUIT\r\n"...)))
+		addr, err := readProxyHeader(r)
+		if tt.hasError {
+			if err == nil {
+				t.Fatalf("expected error, header %q", tt.header)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Error %v, header %q", err, tt.header)
+		}
+		if tt.expected == "" {
+			if addr != nil {
+				t.Fatalf("expected nil, actual %v", addr)
+			}
+		} else if addr == nil || tt.expected != addr.String() {
+			t.Fatalf("expected %v, actual %v", tt.expected, addr)
+		}
+		if rest, _ := io.ReadAll(r); string(rest) != "QUIT\r\n" {
+			t.Fatalf("expected rest of header, actual %q", rest)
+		}
+	}
+}
+
+func TestReadProxyHeader_Short(t *testing.T) {
+	var tests = []struct {
+		header   string
+		hasError bool
+	}{
+		{"PROXY UNKNOWN\r\n", false},
+		{"QUIT\r\n", true},
+	}
+	for _, tt := range tests {
+		c, s := net.Pipe()
+		go c.Write([]byte(tt.header))
+		s.SetReadDeadline(time.Now().Add(time.Second))
+		addr, err := readProxyHeader(bufio.NewReader(s))
+		if tt.hasError != (err != nil) || addr != nil {
+			t.Fatalf("%q: unexpected %v %v", tt.header, addr, err)
+		}
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			t.Fatalf("%q: header should be read without waiting for more bytes", tt.header)
+		}
+		c.Close()
+		s.Close()
+	}
+}
+
+func TestAcceptProxy(t *testing.T) {
+	NewLogger("discard", "error")
+	SetServerConfig(&ServerConfig{TrustedProxies: []string{"127.0.0.0/8"}})
+	defer SetServerConfig(nil)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer l.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.Write([]byte("PROXY TCP4 192.0.2.1 127.0.0.1 12345 9876\r\nQUIT\r\n"))
+		io.Copy(io.Discard, c)
+	}()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer conn.Close()
+	pconn, err := acceptProxy(conn, time.Second)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if actual := pconn.RemoteAddr().String(); actual != "192.0.2.1:12345" {
+		t.Fatalf("expected 192.0.2.1:12345, actual %v", actual)
+	}
+	line, err := bufio.NewReader(pconn).ReadString('\n')
+	if err != nil || line != "QUIT\r\n" {
+		t.Fatalf("expected QUIT, actual %q %v", line, err)
+	}
+}
@@ -0,0 +1,183 @@
+package whoson
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errDropped is returned when a request should be dropped without response.
+var errDropped = errors.New("request dropped")
+
+// RateLimit hold information for token bucket rate limit per source IP.
+// Methods empty limits UDP packets and TCP connections before parsing,
+// otherwise each listed method is limited separately.
+type RateLimit struct {
+	Methods []string
+	Rate    float64
+	Burst   int
+}
+
+type rateRule struct {
+	methods map[MethodType]bool
+	rate    float64
+	burst   float64
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rule   *rateRule
+}
+
+// fill add tokens for elapsed time.
+func (b *tokenBucket) fill(now time.Time) {
+	b.tokens = b.level(now)
+	b.last = now
+}
+
+// level return tokens of bucket at now, without updating the bucket.
+func (b *tokenBucket) level(now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*b.rule.rate
+	if tokens >= b.rule.burst {
+		return b.rule.burst
+	}
+	return tokens
+}
+
+// rateLimiter hold token buckets up to maxEntries, nil rateLimiter allows everything.
+type rateLimiter struct {
+	rules      []*rateRule
+	maxEntries int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limits []RateLimit, maxEntries int) (*rateLimiter, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	if maxEntries <= 0 {
+		maxEntries = maxRateLimitEntries
+	}
+	rl := &rateLimiter{
+		maxEntries: maxEntries,
+		buckets:    make(map[string]*tokenBucket),
+	}
+	for _, limit := range limits {
+		if limit.Rate <= 0 || limit.Burst < 1 {
+			return nil, fmt.Errorf("RateLimits rate %v burst %v must be positive", limit.Rate, limit.Burst)
+		}
+		methods, err := parseMethods(limit.Methods)
+		if err != nil {
+			return nil, errors.Wrap(err, "RateLimits")
+		}
+		rl.rules = append(rl.rules, &rateRule{
+			methods: methods,
+			rate:    limit.Rate,
+			burst:   float64(limit.Burst),
+		})
+	}
+	return rl, nil
+}
+
+// match return true when rule limits m, mUnkownMethod means packet or connection.
+func (r *rateRule) match(m MethodType) bool {
+	if len(r.methods) == 0 {
+		return m == mUnkownMethod
+	}
+	return m != mUnkownMethod && r.methods[m]
+}
+
+// allow take a token from every bucket of ip and m, only when all of them
+// have a token, so that a denied request does not drain the other buckets.
+func (rl *rateLimiter) allow(ip net.IP, m MethodType, now time.Time) bool {
+	if rl == nil {
+		return true
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	var buckets []*tokenBucket
+	for i, r := range rl.rules {
+		if !r.match(m) {
+			continue
+		}
+		b := rl.bucket(fmt.Sprintf("%d/%d/%s", i, m, ip), r, now)
+		if b.tokens < 1 {
+			return false
+		}
+		buckets = append(buckets, b)
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return true
+}
+
+// bucket return bucket of key filled at now, new bucket is full.
+func (rl *rateLimiter) bucket(key string, r *rateRule, now time.Time) *tokenBucket {
+	if b, ok := rl.buckets[key]; ok {
+		b.fill(now)
+		return b
+	}
+	if len(rl.buckets) >= rl.maxEntries {
+		rl.evict(now)
+	}
+	b := &tokenBucket{tokens: r.burst, last: now, rule: r}
+	rl.buckets[key] = b
+	return b
+}
+
+// evict remove full buckets which are same as new ones, and when there
+// are not enough remove least recently used buckets down to nine tenths
+// of maxEntries, so that a drained bucket in use is not reset to full.
+func (rl *rateLimiter) evict(now time.Time) {
+	for k, b := range rl.buckets {
+		if b.level(now) >= b.rule.burst {
+			delete(rl.buckets, k)
+		}
+	}
+	keep := rl.maxEntries - rl.maxEntries/10
+	if len(rl.buckets) < keep {
+		return
+	}
+	keys := make([]string, 0, len(rl.buckets))
+	for k := range rl.buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return rl.buckets[keys[i]].last.Before(rl.buckets[keys[j]].last)
+	})
+	for _, k := range keys[:len(keys)-keep+1] {
+		delete(rl.buckets, k)
+	}
+}
+
+// allowSource check packet or connection rate limit for ip.
+func allowSource(ip net.IP) bool {
+	if getServerConfig().limiter.allow(ip, mUnkownMethod, time.Now()) {
+		return true
+	}
+	expRateLimitedTotal.Add(1)
+	return false
+}
+
+// checkRateLimit check the session remote address is within method rate limit.
+func (ses *Session) checkRateLimit() error {
+	config := getServerConfig()
+	if ses.isLocal() || config.limiter.allow(ses.remoteIP(), ses.cmdMethod, time.Now()) {
+		return nil
+	}
+	expRateLimitedTotal.Add(1)
+	Log("debug", "checkRateLimit:Limited", ses, nil)
+	if ses.protocol == pUDP && config.RateLimitDrop {
+		return errDropped
+	}
+	return errors.New("rate limit exceeded")
+}
@@ -0,0 +1,122 @@
+package whoson
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_allow(t *testing.T) {
+	rl, err := newRateLimiter([]RateLimit{
+		{Rate: 1, Burst: 2},
+		{Methods: []string{"LOGIN"}, Rate: 1, Burst: 1},
+	}, 0)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	now := time.Now()
+	ip1 := net.ParseIP("10.0.0.1")
+	ip2 := net.ParseIP("10.0.0.2")
+
+	var tests = []struct {
+		ip       net.IP
+		method   MethodType
+		after    time.Duration
+		expected bool
+	}{
+		{ip1, mUnkownMethod, 0, true},
+		{ip1, mUnkownMethod, 0, true},
+		{ip1, mUnkownMethod, 0, false},
+		{ip2, mUnkownMethod, 0, true},
+		{ip1, mUnkownMethod, time.Second, true},
+		{ip1, mLogin, time.Second, true},
+		{ip1, mLogin, time.Second, false},
+		{ip1, mQuery, time.Second, true},
+		{ip1, mLogin, 2 * time.Second, true},
+	}
+	for i, tt := range tests {
+		actual := rl.allow(tt.ip, tt.method, now.Add(tt.after))
+		if tt.expected != actual {
+			t.Fatalf("%d: expected %v, actual %v", i, tt.expected, actual)
+		}
+	}
+
+	var nilLimiter *rateLimiter
+	if !nilLimiter.allow(ip1, mLogin, now) {
+		t.Fatalf("expected nil limiter allows everything")
+	}
+}
+
+func TestRateLimiter_evict(t *testing.T) {
+	rl, err := newRateLimiter([]RateLimit{{Rate: 1, Burst: 10}}, 100)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		rl.allow(net.IPv4(10, 0, byte(i>>8), byte(i)), mUnkownMethod, now)
+	}
+	if len(rl.buckets) > 100 {
+		t.Fatalf("expected buckets <= 100, actual %v", len(rl.buckets))
+	}
+}
+
+func TestRateLimiter_allowAllBuckets(t *testing.T) {
+	rl, err := newRateLimiter([]RateLimit{
+		{Methods: []string{"LOGIN"}, Rate: 1, Burst: 1},
+		{Methods: []string{"LOGIN"}, Rate: 0.001, Burst: 2},
+	}, 0)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	now := time.Now()
+	ip := net.ParseIP("10.0.0.1")
+	var tests = []struct {
+		after    time.Duration
+		expected bool
+	}{
+		{0, true},
+		{0, false},
+		{time.Second, true},
+		{2 * time.Second, false},
+	}
+	for i, tt := range tests {
+		if actual := rl.allow(ip, mLogin, now.Add(tt.after)); tt.expected != actual {
+			t.Fatalf("%d: expected %v, actual %v", i, tt.expected, actual)
+		}
+	}
+}
+
+func TestRateLimiter_evictDrained(t *testing.T) {
+	rl, err := newRateLimiter([]RateLimit{{Rate: 0.001, Burst: 1}}, 10)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	now := time.Now()
+	for i := 1; i < 10; i++ {
+		rl.allow(net.IPv4(10, 0, 0, byte(i)), mUnkownMethod, now)
+	}
+	ip := net.ParseIP("10.0.1.1")
+	if !rl.allow(ip, mUnkownMethod, now.Add(time.Second)) {
+		t.Fatalf("expected first request allowed")
+	}
+	for i := 10; i < 20; i++ {
+		rl.allow(net.IPv4(10, 0, 0, byte(i)), mUnkownMethod, now.Add(2*time.Second))
+		if rl.allow(ip, mUnkownMethod, now.Add(3*time.Second)) {
+			t.Fatalf("%d: drained bucket should not be reset by eviction", i)
+		}
+	}
+}
+
+func TestNewRateLimiter_Error(t *testing.T) {
+	var tests = []RateLimit{
+		{Rate: 0, Burst: 1},
+		{Rate: 1, Burst: 0},
+		{Methods: []string{"DELETE"}, Rate: 1, Burst: 1},
+	}
+	for _, tt := range tests {
+		if _, err := newRateLimiter([]RateLimit{tt}, 0); err == nil {
+			t.Fatalf("expected error, limit %+v", tt)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package whoson
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var _ WhosonServer = (*Whoson)(nil)
+
+// Whoson hold information for gRPC whoson service, which changes MainStore
+// like whoson commands, so that changes are synced and run hooks.
+type Whoson struct {
+	UnimplementedWhosonServer
+}
+
+func newWSRecord(sd *StoreData) *WSRecord {
+	r := &WSRecord{
+		IP:     sd.Key(),
+		Data:   sd.Data,
+		Expire: sd.Expire.Unix(),
+		TTL:    int64(sd.TTL / time.Second),
+	}
+	if !sd.Created.IsZero() {
+		r.Created = sd.Created.Unix()
+	}
+	return r
+}
+
+// authorizeRPC check rate limit, ACL and API token in "authorization" metadata of the call.
+func authorizeRPC(ctx context.Context, m MethodType) error {
+	var ip net.IP
+	var remote, authorization string
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+		if a, ok := p.Addr.(*net.TCPAddr); ok {
+			ip = a.IP
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			authorization = v[0]
+		}
+	}
+	switch err := authorizeRequest(ip, remote, m, authorization); err {
+	case nil:
+		return nil
+	case errAPIRateLimited:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errAPIDenied:
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+}
+
+// startRPC authorize m for ctx, and return the function to observe the
+// allowed request. Denied request is observed at once.
+func startRPC(ctx context.Context, m MethodType) (func(), error) {
+	start := time.Now()
+	if err := authorizeRPC(ctx, m); err != nil {
+		observeRequest(protocolGRPC, m, start, resultDenied)
+		return nil, err
+	}
+	return func() {
+		observeRequest(protocolGRPC, m, start, resultAllowed)
+	}, nil
+}
+
+func parseRPCKey(ip string) (*StoreData, error) {
+	sd, err := parseKey(ip)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "IP %q parse error", ip)
+	}
+	return sd, nil
+}
+
+// Login store data like LOGIN, TTL is seconds and default when zero.
+func (s *Whoson) Login(ctx context.Context, req *WSLoginRequest) (*WSRecord, error) {
+	observe, err := startRPC(ctx, mLogin)
+	if err != nil {
+		return nil, err
+	}
+	defer observe()
+	sd, err := parseRPCKey(req.IP)
+	if err != nil {
+		return nil, err
+	}
+	if req.TTL < 0 {
+		return nil, status.Error(codes.InvalidArgument, "TTL must not be negative")
+	}
+	if strings.ContainsAny(req.Data, "\r\n") {
+		return nil, status.Error(codes.InvalidArgument, errAPIBadData.Error())
+	}
+	expCommandLoginTotal.Add(1)
+	sd.Data = req.Data
+	loginData(ctx, sd, time.Duration(req.TTL)*time.Second)
+	return newWSRecord(sd), nil
+}
+
+// Logout delete data like LOGOUT, and return deleted data.
+func (s *Whoson) Logout(ctx context.Context, req *WSLogoutRequest) (*WSRecord, error) {
+	observe, err := startRPC(ctx, mLogout)
+	if err != nil {
+		return nil, err
+	}
+	defer observe()
+	key, err := parseRPCKey(req.IP)
+	if err != nil {
+		return nil, err
+	}
+	expCommandLogoutTotal.Add(1)
+	sd, ok := logoutData(ctx, key.Key())
+	if !ok {
+		return nil, status.Error(codes.NotFound, errAPINotFound.Error())
+	}
+	if sd == nil {
+		sd = key
+	}
+	return newWSRecord(sd), nil
+}
+
+// Query find data of IP address or IP address with single port like QUERY.
+func (s *Whoson) Query(ctx context.Context, req *WSQueryRequest) (*WSRecord, error) {
+	observe, err := startRPC(ctx, mQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer observe()
+	key, err := parseQueryKey(req.IP)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	expCommandQueryTotal.Add(1)
+	sd, err := queryData(ctx, key.IP, key.PortFrom)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, errAPINotFound.Error())
+	}
+	return newWSRecord(sd), nil
+}
+
+// Refresh update expire time like REFRESH, TTL is seconds and zero keeps ttl of data.
+func (s *Whoson) Refresh(ctx context.Context, req *WSRefreshRequest) (*WSRecord, error) {
+	observe, err := startRPC(ctx, mRefresh)
+	if err != nil {
+		return nil, err
+	}
+	defer observe()
+	key, err := parseRPCKey(req.IP)
+	if err != nil {
+		return nil, err
+	}
+	if req.TTL < 0 {
+		return nil, status.Error(codes.InvalidArgument, "TTL must not be negative")
+	}
+	expCommandRefreshTotal.Add(1)
+	sd, err := refreshData(ctx, key.Key(), time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, errAPINotFound.Error())
+	}
+	return newWSRecord(sd), nil
+}
+
+// List return data logged in by Data or all data from Offset up to Limit, sorted by IP.
+func (s *Whoson) List(ctx context.Context, req *WSListRequest) (*WSListResponse, error) {
+	observe, err := startRPC(ctx, mLookup)
+	if err != nil {
+		return nil, err
+	}
+	defer observe()
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = apiDefaultLimit
+	}
+	if req.Offset < 0 || limit < 0 || limit > apiMaxLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "offset must not be negative, and limit must be 0 to %d", apiMaxLimit)
+	}
+	total, sds := listData(req.Data, int(req.Offset), limit)
+	res := &WSListResponse{Total: int32(total)}
+	for _, sd := range sds {
+		res.Records = append(res.Records, newWSRecord(sd))
+	}
+	return res, nil
+}
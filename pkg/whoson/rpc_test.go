@@ -0,0 +1,185 @@
+package whoson
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func startWhosonRPC(t *testing.T) (string, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	g := grpc.NewServer()
+	RegisterWhosonServer(g, &Whoson{})
+	go g.Serve(lis)
+	return lis.Addr().String(), g.Stop
+}
+
+// expectRecord return function taking result of ControlClient, which fails on error.
+func expectRecord(t *testing.T) func(*WSRecord, error) *WSRecord {
+	return func(r *WSRecord, err error) *WSRecord {
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		return r
+	}
+}
+
+func TestWhoson_RPC(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{
+		TTLMax: 600,
+		Hooks:  []Hook{{Events: []string{hLogin, hLogout}, Command: []string{"/bin/true"}}},
+	})
+	addr, stop := startWhosonRPC(t)
+	defer stop()
+	c, err := NewControlClient(addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	r := expectRecord(t)(c.Login(ctx, "198.18.3.1", "rpcuser1", time.Hour))
+	if r.IP != "198.18.3.1" || r.Data != "rpcuser1" || r.TTL != 600 {
+		t.Fatalf("unexpected login record %v", r)
+	}
+	if j := <-hookQueue; j.event.Event != hLogin || j.event.IP != "198.18.3.1" {
+		t.Fatalf("login should queue hook, actual %v", j.event)
+	}
+	if r = expectRecord(t)(c.Query(ctx, "198.18.3.1")); r.Data != "rpcuser1" {
+		t.Fatalf("unexpected query %v", r)
+	}
+	if r = expectRecord(t)(c.Refresh(ctx, "198.18.3.1", 0)); r.TTL != 600 {
+		t.Fatalf("unexpected refresh %v", r)
+	}
+	c.Login(ctx, "198.18.3.0/30", "rpcuser1", 0)
+	<-hookQueue
+	l, err := c.List(ctx, "rpcuser1", 1, 1)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if l.Total != 2 || len(l.Records) != 1 || l.Records[0].IP != "198.18.3.1" {
+		t.Fatalf("unexpected list %v", l)
+	}
+	if r = expectRecord(t)(c.Logout(ctx, "198.18.3.1")); r.Data != "rpcuser1" {
+		t.Fatalf("unexpected logout %v", r)
+	}
+	if j := <-hookQueue; j.event.Event != hLogout {
+		t.Fatalf("logout should queue hook, actual %v", j.event)
+	}
+	c.Logout(ctx, "198.18.3.0/30")
+	<-hookQueue
+}
+
+func TestWhoson_RPCErrors(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{})
+	addr, stop := startWhosonRPC(t)
+	defer stop()
+	c, err := NewControlClient(addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	var tests = []struct {
+		f        func() error
+		expected codes.Code
+	}{
+		{func() error { _, err := c.Query(ctx, "198.18.3.1"); return err }, codes.NotFound},
+		{func() error { _, err := c.Logout(ctx, "198.18.3.1"); return err }, codes.NotFound},
+		{func() error { _, err := c.Refresh(ctx, "198.18.3.1", 0); return err }, codes.NotFound},
+		{func() error { _, err := c.Login(ctx, "198.18.3.999", "rpcuser1", 0); return err }, codes.InvalidArgument},
+		{func() error { _, err := c.Login(ctx, "198.18.3.1", "rpcuser1\n", 0); return err }, codes.InvalidArgument},
+		{func() error { _, err := c.Query(ctx, "198.18.3.0/24"); return err }, codes.InvalidArgument},
+		{func() error { _, err := c.List(ctx, "", 0, apiMaxLimit+1); return err }, codes.InvalidArgument},
+	}
+	for i, tt := range tests {
+		if code := status.Code(tt.f()); code != tt.expected {
+			t.Fatalf("%d: expected %v, actual %v", i, tt.expected, code)
+		}
+	}
+}
+
+func TestWhoson_Authorize(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	addr, stop := startWhosonRPC(t)
+	defer stop()
+
+	var tests = []struct {
+		config   *ServerConfig
+		opts     []grpc.DialOption
+		expected codes.Code
+	}{
+		{&ServerConfig{APITokens: map[string]string{"tool": "t0ken"}}, []grpc.DialOption{WithAPIToken("t0ken")}, codes.NotFound},
+		{&ServerConfig{APITokens: map[string]string{"tool": "t0ken"}}, nil, codes.Unauthenticated},
+		{&ServerConfig{APITokens: map[string]string{"tool": "t0ken"}}, []grpc.DialOption{WithAPIToken("wrong")}, codes.Unauthenticated},
+		{&ServerConfig{ACL: []ACLRule{{Network: "192.0.2.0/24", Methods: []string{"ALL"}}}}, nil, codes.PermissionDenied},
+	}
+	for i, tt := range tests {
+		SetServerConfig(tt.config)
+		c, err := NewControlClient(addr, tt.opts...)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		_, err = c.Query(context.Background(), "198.18.4.1")
+		c.Close()
+		if code := status.Code(err); code != tt.expected {
+			t.Fatalf("%d: expected %v, actual %v", i, tt.expected, code)
+		}
+	}
+}
+
+func TestWhoson_AuthRequired(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{AuthRequired: true})
+	addr, stop := startWhosonRPC(t)
+	defer stop()
+	c, err := NewControlClient(addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	var tests = []struct {
+		f        func() error
+		expected codes.Code
+	}{
+		{func() error { _, err := c.Login(ctx, "198.18.4.2", "rpcuser3", 0); return err }, codes.Unauthenticated},
+		{func() error { _, err := c.Logout(ctx, "198.18.4.2"); return err }, codes.Unauthenticated},
+		{func() error { _, err := c.Refresh(ctx, "198.18.4.2", 0); return err }, codes.Unauthenticated},
+		{func() error { _, err := c.Query(ctx, "198.18.4.2"); return err }, codes.NotFound},
+	}
+	for i, tt := range tests {
+		if code := status.Code(tt.f()); code != tt.expected {
+			t.Fatalf("%d: expected %v, actual %v", i, tt.expected, code)
+		}
+	}
+
+	SetServerConfig(&ServerConfig{AuthRequired: true, APITokens: map[string]string{"tool": "t0ken"}})
+	ct, err := NewControlClient(addr, WithAPIToken("t0ken"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer ct.Close()
+	expectRecord(t)(ct.Login(ctx, "198.18.4.2", "rpcuser3", 0))
+	expectRecord(t)(ct.Logout(ctx, "198.18.4.2"))
+}
@@ -0,0 +1,83 @@
+package whoson
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ControlClient hold information for client of gRPC whoson service on the control port.
+type ControlClient struct {
+	conn   *grpc.ClientConn
+	client WhosonClient
+}
+
+// NewControlClient return new ControlClient struct pointer for server, the
+// connection is insecure unless transport credentials are given by opts.
+func NewControlClient(server string, opts ...grpc.DialOption) (*ControlClient, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	conn, err := grpc.NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ControlClient{
+		conn:   conn,
+		client: NewWhosonClient(conn),
+	}, nil
+}
+
+// WithAPIToken return grpc.DialOption sending token of APITokens with every call.
+func WithAPIToken(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(apiToken(token))
+}
+
+// apiToken is grpc credentials.PerRPCCredentials of APITokens.
+type apiToken string
+
+func (t apiToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t apiToken) RequireTransportSecurity() bool {
+	return false
+}
+
+// Login store data of ip, ttl is rounded to seconds and server default when zero.
+func (c *ControlClient) Login(ctx context.Context, ip string, data string, ttl time.Duration) (*WSRecord, error) {
+	return c.client.Login(ctx, &WSLoginRequest{IP: ip, Data: data, TTL: int64(ttl / time.Second)})
+}
+
+// Logout delete data of ip, and return deleted data.
+func (c *ControlClient) Logout(ctx context.Context, ip string) (*WSRecord, error) {
+	return c.client.Logout(ctx, &WSLogoutRequest{IP: ip})
+}
+
+// Query return data of ip.
+func (c *ControlClient) Query(ctx context.Context, ip string) (*WSRecord, error) {
+	return c.client.Query(ctx, &WSQueryRequest{IP: ip})
+}
+
+// Refresh update expire time of ip, ttl is rounded to seconds and zero keeps ttl of data.
+func (c *ControlClient) Refresh(ctx context.Context, ip string, ttl time.Duration) (*WSRecord, error) {
+	return c.client.Refresh(ctx, &WSRefreshRequest{IP: ip, TTL: int64(ttl / time.Second)})
+}
+
+// List return data logged in by user or all data when user is empty, from
+// offset up to limit sorted by IP, limit is server default when zero.
+func (c *ControlClient) List(ctx context.Context, user string, offset, limit int) (*WSListResponse, error) {
+	return c.client.List(ctx, &WSListRequest{Data: user, Offset: int32(offset), Limit: int32(limit)})
+}
+
+// Watch return stream of store events matching req, the stream is closed by cancel of ctx.
+func (c *ControlClient) Watch(ctx context.Context, req *WSWatchRequest) (grpc.ServerStreamingClient[WSEvent], error) {
+	return c.client.Watch(ctx, req)
+}
+
+// Close close the connection.
+func (c *ControlClient) Close() error {
+	return c.conn.Close()
+}
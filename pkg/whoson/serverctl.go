@@ -15,6 +15,7 @@ import (
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/olekukonko/tablewriter/tw"
+	"github.com/pkg/errors"
 )
 
 // ServerCtl hold information for server control.
@@ -34,6 +35,42 @@ func NewServerCtl(server string) *ServerCtl {
 
 // Dump Set grpc repository to sc.dumpResp
 func (sc *ServerCtl) Dump() error {
+	return sc.call(func(ctx context.Context, client SyncClient) (*WSDumpResponse, error) {
+		return client.Dump(ctx, &WSDumpRequest{})
+	})
+}
+
+// LookupUser Set data logged in by user to sc.dumpResp
+func (sc *ServerCtl) LookupUser(user string) error {
+	return sc.call(func(ctx context.Context, client SyncClient) (*WSDumpResponse, error) {
+		return client.Lookup(ctx, &WSLookupRequest{Data: user})
+	})
+}
+
+// HistoryByIP Set login history of ip at t to sc.dumpResp
+func (sc *ServerCtl) HistoryByIP(ip string, t time.Time) error {
+	return sc.history(&WSHistoryRequest{IP: ip, Time: t.Unix()})
+}
+
+// HistoryByUser Set login history of user from from to to to sc.dumpResp
+func (sc *ServerCtl) HistoryByUser(user string, from, to time.Time) error {
+	return sc.history(&WSHistoryRequest{Data: user, From: from.Unix(), To: to.Unix()})
+}
+
+func (sc *ServerCtl) history(req *WSHistoryRequest) error {
+	err := sc.call(func(ctx context.Context, client SyncClient) (*WSDumpResponse, error) {
+		return client.History(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+	if sc.dumpResp.Rcode != 1 {
+		return errors.New(sc.dumpResp.Msg)
+	}
+	return nil
+}
+
+func (sc *ServerCtl) call(f func(context.Context, SyncClient) (*WSDumpResponse, error)) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
@@ -47,10 +84,7 @@ func (sc *ServerCtl) Dump() error {
 	}
 	defer conn.Close()
 
-	client := NewSyncClient(conn)
-
-	req := &WSDumpRequest{}
-	r, err := client.Dump(ctx, req)
+	r, err := f(ctx, NewSyncClient(conn))
 	if err != nil {
 		return err
 	}
@@ -76,8 +110,7 @@ func (sc *ServerCtl) WriteJSON() error {
 	return nil
 }
 
-// WriteTable Output Table with io.Writer
-func (sc *ServerCtl) WriteTable() error {
+func (sc *ServerCtl) newTable() *tablewriter.Table {
 	// New API: Create table with option-based approach
 	t := tablewriter.NewTable(sc.out,
 		tablewriter.WithConfig(tablewriter.Config{
@@ -91,6 +124,12 @@ func (sc *ServerCtl) WriteTable() error {
 			Borders: tw.BorderNone, // Disable borders
 		}),
 	)
+	return t
+}
+
+// WriteTable Output Table with io.Writer
+func (sc *ServerCtl) WriteTable() error {
+	t := sc.newTable()
 
 	// Set headers (using new Header method)
 	t.Header("Expire", "IP", "Data")
@@ -105,13 +144,13 @@ func (sc *ServerCtl) WriteTable() error {
 		if sd[i].Expire.Unix() < sd[j].Expire.Unix() {
 			return true
 		}
-		return (sd[i].Expire.Unix() == sd[j].Expire.Unix()) && (sd[i].IP.String() < sd[j].IP.String())
+		return (sd[i].Expire.Unix() == sd[j].Expire.Unix()) && (sd[i].Key() < sd[j].Key())
 	})
 
 	if len(sd) > 0 {
 		for _, v := range sd {
 			// Append method (with error handling)
-			err := t.Append([]string{v.Expire.Format("2006-01-02 15:04:05"), v.IP.String(), v.Data})
+			err := t.Append([]string{v.Expire.Format("2006-01-02 15:04:05"), v.Key(), v.Data})
 			if err != nil {
 				return fmt.Errorf("failed to append row: %w", err)
 			}
@@ -123,3 +162,28 @@ func (sc *ServerCtl) WriteTable() error {
 	}
 	return nil
 }
+
+// WriteHistoryTable Output history Table with io.Writer
+func (sc *ServerCtl) WriteHistoryTable() error {
+	t := sc.newTable()
+	t.Header("Login", "Logout", "IP", "Data")
+
+	var records []*HistoryRecord
+	err := json.Unmarshal(sc.dumpResp.Json, &records)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	for _, v := range records {
+		err := t.Append([]string{v.Login.Format("2006-01-02 15:04:05"), v.Logout.Format("2006-01-02 15:04:05"), v.IP, v.Data})
+		if err != nil {
+			return fmt.Errorf("failed to append row: %w", err)
+		}
+	}
+	if err := t.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package whoson
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func startShutdownTCPServer(t *testing.T) (*TCPServer, string, chan error) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	NewIDGenerator(uint(1))
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	s := NewTCPServer()
+	done := make(chan error, 1)
+	go func() { done <- s.ServeTCP(l) }()
+	return s, l.Addr().String(), done
+}
+
+// startPartialCommand send first line of QUERY, the session is active until the blank line.
+func startPartialCommand(t *testing.T, addr string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if _, err := conn.Write([]byte("QUERY 192.0.2.1\r\n")); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	return conn, bufio.NewReader(conn)
+}
+
+func TestTCPServer_Shutdown(t *testing.T) {
+	s, addr, done := startShutdownTCPServer(t)
+
+	idle, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer idle.Close()
+	if _, err := idle.Query("192.0.2.1"); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	conn, r := startPartialCommand(t, addr)
+	defer conn.Close()
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- s.Shutdown(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := conn.Write([]byte("\r\n")); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(time.Second))
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if expected := "-Not Logged in\r\n"; line != expected {
+		t.Fatalf("expected %q, actual %q", expected, line)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("expected nil, actual %v", err)
+	}
+	if err := <-done; err != ErrServerClosed {
+		t.Fatalf("expected %v, actual %v", ErrServerClosed, err)
+	}
+}
+
+func TestTCPServer_ShutdownTimeout(t *testing.T) {
+	s, addr, done := startShutdownTCPServer(t)
+	conn, r := startPartialCommand(t, addr)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, actual %v", context.DeadlineExceeded, err)
+	}
+	conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Fatalf("connection should be closed")
+	}
+	<-done
+}
+
+func TestUDPServer_Shutdown(t *testing.T) {
+	NewLogger("discard", "error")
+	c, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	s := NewUDPServer()
+	done := make(chan error, 1)
+	go func() { done <- s.ServeUDP(c) }()
+
+	client, err := Dial("udp", c.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer client.Close()
+	if _, err := client.Query("192.0.2.1"); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("expected nil, actual %v", err)
+	}
+	if err := <-done; err != ErrServerClosed {
+		t.Fatalf("expected %v, actual %v", ErrServerClosed, err)
+	}
+}
+
+func TestWorker_Drain(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	NewIDGenerator(uint(1))
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer pc.Close()
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer client.Close()
+
+	s := NewUDPServer()
+	const queued = 3
+	for i := 0; i < queued; i++ {
+		b := s.getBuffer()
+		b.count = copy(b.buf, "QUERY 192.0.2.1\r\n\r\n")
+		ses, err := NewSessionUDP(pc, client.LocalAddr(), b)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		s.enqueue(ses)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.wg.Add(1)
+	(&Worker{s: s}).Run(ctx)
+
+	client.SetDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, udpByteSize)
+	for i := 0; i < queued; i++ {
+		if _, _, err := client.ReadFrom(buf); err != nil {
+			t.Fatalf("response %d: Error %v", i, err)
+		}
+	}
+}
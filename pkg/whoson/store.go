@@ -4,17 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-var syncChan chan *WSRequest
+var syncChan chan *syncRequest
+
+// syncRequest hold WSRequest to SyncRemote peers, and span context of the store mutation.
+type syncRequest struct {
+	req  *WSRequest
+	span trace.SpanContext
+}
 
 // Store is hold Store API.
 type Store interface {
@@ -28,11 +38,185 @@ type Store interface {
 	SyncDel(k string) bool
 }
 
+// RefreshStore is optional Store API to update expire time of stored data.
+type RefreshStore interface {
+	Refresh(k string, ttl time.Duration) (*StoreData, error)
+	SyncRefresh(k string, w *StoreData) bool
+	SetExpire(k string, expire time.Time) (*StoreData, error)
+}
+
+// LookupStore is optional Store API to find data by port range and prefix, or by user.
+type LookupStore interface {
+	Lookup(ip net.IP, port int) (*StoreData, error)
+	FindByUser(user string) []*StoreData
+}
+
+// ContextStore is optional Store API of mutations with context.Context,
+// which carries the trace of the request to the store and SyncRemote peers.
+type ContextStore interface {
+	SetContext(ctx context.Context, k string, w *StoreData)
+	DelContext(ctx context.Context, k string) bool
+	RefreshContext(ctx context.Context, k string, ttl time.Duration) (*StoreData, error)
+	SetExpireContext(ctx context.Context, k string, expire time.Time) (*StoreData, error)
+}
+
 var _ Store = (*MemStore)(nil)
+var _ RefreshStore = (*MemStore)(nil)
+var _ LookupStore = (*MemStore)(nil)
+var _ ContextStore = (*MemStore)(nil)
+
+// expireStore is implemented by stores which delete expired data atomically,
+// and sync the deletion to SyncRemote peers as expiry.
+type expireStore interface {
+	expire(ctx context.Context, k string, now time.Time) (*StoreData, bool)
+}
+
+// popStore is implemented by stores which return data deleted without sync, even if expired.
+type popStore interface {
+	pop(k string) (*StoreData, bool)
+}
+
+// storeSet set data to store, with ctx if store is ContextStore.
+func storeSet(ctx context.Context, store Store, k string, w *StoreData) {
+	if cs, ok := store.(ContextStore); ok {
+		cs.SetContext(ctx, k, w)
+		return
+	}
+	store.Set(k, w)
+}
+
+// storeDel delete data from store, with ctx if store is ContextStore.
+func storeDel(ctx context.Context, store Store, k string) bool {
+	if cs, ok := store.(ContextStore); ok {
+		return cs.DelContext(ctx, k)
+	}
+	return store.Del(k)
+}
+
+// storeRefresh extend expire time of data in store, with ctx if store is ContextStore.
+func storeRefresh(ctx context.Context, store Store, k string, ttl time.Duration) (*StoreData, error) {
+	if cs, ok := store.(ContextStore); ok {
+		return cs.RefreshContext(ctx, k, ttl)
+	}
+	if rs, ok := store.(RefreshStore); ok {
+		return rs.Refresh(k, ttl)
+	}
+	return storeUpdate(ctx, store, k, func(sd *StoreData) {
+		if ttl > 0 {
+			sd.TTL = ttl
+		}
+		sd.UpdateExpire()
+	})
+}
+
+// storeUpdate set copy of data of k modified by f to store, which is not RefreshStore.
+func storeUpdate(ctx context.Context, store Store, k string, f func(sd *StoreData)) (*StoreData, error) {
+	item, err := store.Get(k)
+	if err != nil {
+		return nil, err
+	}
+	sd := *item
+	f(&sd)
+	storeSet(ctx, store, k, &sd)
+	return &sd, nil
+}
+
+// storeSyncRefresh update expire time of data in store from remote host.
+func storeSyncRefresh(store Store, k string, w *StoreData) bool {
+	if rs, ok := store.(RefreshStore); ok {
+		return rs.SyncRefresh(k, w)
+	}
+	item, err := store.Get(k)
+	if err != nil {
+		return false
+	}
+	sd := *item
+	sd.Expire = w.Expire
+	if w.TTL > 0 {
+		sd.TTL = w.TTL
+	}
+	store.SyncSet(k, &sd)
+	return true
+}
+
+// storeLookup return data of ip and port in store, or of ip only if store is not LookupStore.
+func storeLookup(store Store, ip net.IP, port int) (*StoreData, error) {
+	if ls, ok := store.(LookupStore); ok {
+		return ls.Lookup(ip, port)
+	}
+	return store.Get(ip.String())
+}
+
+// storeFindByUser return all data logged in by user in store.
+func storeFindByUser(store Store, user string) []*StoreData {
+	if ls, ok := store.(LookupStore); ok {
+		return ls.FindByUser(user)
+	}
+	var sds []*StoreData
+	for k, item := range store.Items() {
+		if dataUser(item.Data) != user {
+			continue
+		}
+		if sd, err := store.Get(k); err == nil {
+			sds = append(sds, sd)
+		}
+	}
+	return sds
+}
+
+// storeExpire delete item from store if it is expired at now, and return deleted data.
+func storeExpire(ctx context.Context, store Store, item *StoreData, now time.Time) (*StoreData, bool) {
+	if es, ok := store.(expireStore); ok {
+		return es.expire(ctx, item.Key(), now)
+	}
+	return item, storeDel(ctx, store, item.Key())
+}
+
+// storeSyncPop delete data of k from store without sync, and return deleted data.
+func storeSyncPop(store Store, k string) (*StoreData, bool) {
+	if ps, ok := store.(popStore); ok {
+		return ps.pop(k)
+	}
+	sd, _ := store.Get(k)
+	return sd, store.SyncDel(k)
+}
+
+// storeSetExpire set expire time to data in store, with ctx if store is ContextStore.
+func storeSetExpire(ctx context.Context, store Store, k string, expire time.Time) (*StoreData, error) {
+	if cs, ok := store.(ContextStore); ok {
+		return cs.SetExpireContext(ctx, k, expire)
+	}
+	if rs, ok := store.(RefreshStore); ok {
+		return rs.SetExpire(k, expire)
+	}
+	return storeUpdate(ctx, store, k, func(sd *StoreData) {
+		sd.Expire = expire
+	})
+}
+
+// keyLockCount is number of locks striped by key of MemStore.
+const keyLockCount = 64
+
+// keyLocks serialize mutations of the same key of MemStore, so that data and
+// indexes of a key are not updated by a read-modify-write racing with delete.
+type keyLocks [keyLockCount]sync.Mutex
+
+// lock lock k, and return the function to unlock k.
+func (kl *keyLocks) lock(k string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(k))
+	m := &kl[h.Sum32()%keyLockCount]
+	m.Lock()
+	return m.Unlock
+}
 
 // MemStore hold information for cmap.
 type MemStore struct {
 	cmap       cmap.ConcurrentMap[string, *StoreData]
+	locks      *keyLocks
+	prefixes   *prefixIndex
+	ports      *portIndex
+	users      *userIndex
 	SyncRemote bool
 	Store
 }
@@ -41,6 +225,10 @@ type MemStore struct {
 func NewMemStore() Store {
 	return MemStore{
 		cmap:       cmap.New[*StoreData](),
+		locks:      &keyLocks{},
+		prefixes:   newPrefixIndex(),
+		ports:      newPortIndex(),
+		users:      newUserIndex(),
 		SyncRemote: false,
 	}
 }
@@ -57,69 +245,291 @@ func NewMainStoreEnableSyncRemote() {
 	if MainStore == nil {
 		MainStore = MemStore{
 			cmap:       cmap.New[*StoreData](),
+			locks:      &keyLocks{},
+			prefixes:   newPrefixIndex(),
+			ports:      newPortIndex(),
+			users:      newUserIndex(),
 			SyncRemote: true,
 		}
 	}
 	if syncChan == nil {
-		syncChan = make(chan *WSRequest, 32)
+		syncChan = make(chan *syncRequest, 32)
 	}
 }
 
 // Set data to cmap store.
 func (ms MemStore) Set(k string, w *StoreData) {
-	ms.cmap.Set(k, w)
+	ms.SetContext(context.Background(), k, w)
+}
+
+// SetContext set data to cmap store, with trace of ctx.
+func (ms MemStore) SetContext(ctx context.Context, k string, w *StoreData) {
+	ctx, span := startStoreSpan(ctx, "MemStore.Set", k)
+	defer span.End()
+	ms.set(k, w)
 
 	if ms.SyncRemote {
 		r := &WSRequest{
-			Expire: w.Expire.Unix(),
-			IP:     w.IP.String(),
-			Data:   w.Data,
-			Method: "Set",
+			Expire:  w.Expire.Unix(),
+			IP:      w.Key(),
+			Data:    w.Data,
+			Method:  "Set",
+			TTL:     int64(w.TTL / time.Second),
+			Created: w.Created.Unix(),
 		}
-		syncChan <- r
+		enqueueSync(ctx, r)
 	}
 }
 
+// enqueueSync queue r to syncChan, with span context to continue the trace on sync.
+func enqueueSync(ctx context.Context, r *WSRequest) {
+	_, span := startSpan(ctx, "syncChan.enqueue", trace.WithAttributes(
+		attribute.String("whoson.sync.method", r.Method),
+		attribute.Int("whoson.sync.queue_length", len(syncChan)),
+	))
+	defer span.End()
+	syncChan <- &syncRequest{req: r, span: span.SpanContext()}
+}
+
 // SyncSet data to remote host store.
 func (ms MemStore) SyncSet(k string, w *StoreData) {
-	ms.cmap.Set(k, w)
+	ms.set(k, w)
 }
 
-// Get data from cmap store.
+// set data to cmap store and indexes.
+func (ms MemStore) set(k string, w *StoreData) {
+	defer ms.locks.lock(k)()
+	var old *StoreData
+	ms.cmap.Upsert(k, w, func(exist bool, valueInMap *StoreData, newValue *StoreData) *StoreData {
+		if exist {
+			old = valueInMap
+		}
+		return newValue
+	})
+	if old != nil && dataUser(old.Data) != dataUser(w.Data) {
+		ms.users.remove(dataUser(old.Data), k)
+	}
+	ms.users.add(dataUser(w.Data), k)
+	ms.prefixes.add(k, w)
+	ms.ports.add(k, w)
+}
+
+// remove data from cmap store and indexes.
+func (ms MemStore) remove(k string) bool {
+	_, ok := ms.pop(k)
+	return ok
+}
+
+// pop remove data from cmap store and indexes, and return the removed data.
+func (ms MemStore) pop(k string) (*StoreData, bool) {
+	defer ms.locks.lock(k)()
+	return ms.popLocked(k)
+}
+
+func (ms MemStore) popLocked(k string) (*StoreData, bool) {
+	ms.prefixes.remove(k)
+	ms.ports.remove(k)
+	item, ok := ms.cmap.Pop(k)
+	if ok {
+		ms.users.remove(dataUser(item.Data), k)
+	}
+	return item, ok
+}
+
+// expire remove data of k if it is expired at now, and sync it to SyncRemote peers as Expire.
+func (ms MemStore) expire(ctx context.Context, k string, now time.Time) (*StoreData, bool) {
+	ctx, span := startStoreSpan(ctx, "MemStore.Expire", k)
+	defer span.End()
+	item, ok := ms.popExpired(k, now)
+	if ok && ms.SyncRemote {
+		r := &WSRequest{
+			IP:     k,
+			Method: "Expire",
+		}
+		enqueueSync(ctx, r)
+	}
+	return item, ok
+}
+
+// popExpired remove data of k without sync, only if it is expired at now.
+func (ms MemStore) popExpired(k string, now time.Time) (*StoreData, bool) {
+	defer ms.locks.lock(k)()
+	item, ok := ms.cmap.Get(k)
+	if !ok || item.Expire.After(now) {
+		return nil, false
+	}
+	return ms.popLocked(k)
+}
+
+// Get data from cmap store. Expired data is deleted and not found.
 func (ms MemStore) Get(k string) (*StoreData, error) {
 	if item, ok := ms.cmap.Get(k); ok {
-		if item.Expire.After(time.Now()) {
+		now := time.Now()
+		if item.Expire.After(now) {
 			return item, nil
 		}
-		ms.SyncDel(k)
+		if sd, ok := ms.popExpired(k, now); ok {
+			expiredData(sd)
+		}
 	}
 	return nil, errors.New("data not found")
 }
 
 // Del delete data from cmap store.
 func (ms MemStore) Del(k string) bool {
+	return ms.DelContext(context.Background(), k)
+}
+
+// DelContext delete data from cmap store, with trace of ctx.
+func (ms MemStore) DelContext(ctx context.Context, k string) bool {
+	ctx, span := startStoreSpan(ctx, "MemStore.Del", k)
+	defer span.End()
 	if ms.SyncRemote {
 		r := &WSRequest{
 			IP:     k,
 			Method: "Del",
 		}
-		syncChan <- r
+		enqueueSync(ctx, r)
 	}
-
-	if ms.cmap.Has(k) {
-		ms.cmap.Remove(k)
-		return true
-	}
-	return false
+	return ms.remove(k)
 }
 
 // SyncDel data from remote host store.
 func (ms MemStore) SyncDel(k string) bool {
-	if ms.cmap.Has(k) {
-		ms.cmap.Remove(k)
-		return true
+	return ms.remove(k)
+}
+
+// Lookup return data of the narrowest port range of ip containing port,
+// or of ip, or of the longest prefix containing ip. Port ranges are not
+// searched if port is zero.
+func (ms MemStore) Lookup(ip net.IP, port int) (*StoreData, error) {
+	if port > 0 {
+		if sd := ms.lookupPort(ip, port); sd != nil {
+			return sd, nil
+		}
+	}
+	if sd, err := ms.Get(ip.String()); err == nil {
+		return sd, nil
+	}
+	for _, k := range ms.prefixes.lookupKeys(ip) {
+		if sd, err := ms.Get(k); err == nil {
+			return sd, nil
+		}
+	}
+	return nil, errors.New("data not found")
+}
+
+func (ms MemStore) lookupPort(ip net.IP, port int) *StoreData {
+	var found *StoreData
+	for _, k := range ms.ports.lookupKeys(ip) {
+		sd, err := ms.Get(k)
+		if err != nil || port < sd.PortFrom || port > sd.PortTo {
+			continue
+		}
+		if found == nil || sd.PortTo-sd.PortFrom < found.PortTo-found.PortFrom {
+			found = sd
+		}
+	}
+	return found
+}
+
+// FindByUser return all data logged in by user.
+func (ms MemStore) FindByUser(user string) []*StoreData {
+	var sds []*StoreData
+	for _, k := range ms.users.keys(user) {
+		sd, err := ms.Get(k)
+		if err != nil {
+			continue
+		}
+		if dataUser(sd.Data) != user {
+			ms.users.remove(user, k)
+			continue
+		}
+		sds = append(sds, sd)
+	}
+	return sds
+}
+
+// Refresh extend expire time of stored data, record ttl is used if ttl is zero.
+func (ms MemStore) Refresh(k string, ttl time.Duration) (*StoreData, error) {
+	return ms.RefreshContext(context.Background(), k, ttl)
+}
+
+// RefreshContext extend expire time of stored data with trace of ctx, record ttl is used if ttl is zero.
+func (ms MemStore) RefreshContext(ctx context.Context, k string, ttl time.Duration) (*StoreData, error) {
+	ctx, span := startStoreSpan(ctx, "MemStore.Refresh", k)
+	defer span.End()
+	sd, ok := ms.update(k, true, func(sd *StoreData) {
+		if ttl > 0 {
+			sd.TTL = ttl
+		}
+		sd.UpdateExpire()
+	})
+	if !ok {
+		err := errors.New("data not found")
+		spanError(span, err)
+		return nil, err
+	}
+	ms.syncRefresh(ctx, k, sd)
+	return sd, nil
+}
+
+// SetExpire set expire time to stored data.
+func (ms MemStore) SetExpire(k string, expire time.Time) (*StoreData, error) {
+	return ms.SetExpireContext(context.Background(), k, expire)
+}
+
+// SetExpireContext set expire time to stored data, with trace of ctx.
+func (ms MemStore) SetExpireContext(ctx context.Context, k string, expire time.Time) (*StoreData, error) {
+	ctx, span := startStoreSpan(ctx, "MemStore.SetExpire", k)
+	defer span.End()
+	sd, ok := ms.update(k, true, func(sd *StoreData) {
+		sd.Expire = expire
+	})
+	if !ok {
+		err := errors.New("data not found")
+		spanError(span, err)
+		return nil, err
 	}
-	return false
+	ms.syncRefresh(ctx, k, sd)
+	return sd, nil
+}
+
+// update store copy of data of k modified by f, only while k is stored, and
+// not expired if live is true. ok is false when no data is updated.
+func (ms MemStore) update(k string, live bool, f func(sd *StoreData)) (updated *StoreData, ok bool) {
+	defer ms.locks.lock(k)()
+	item, ok := ms.cmap.Get(k)
+	if !ok || live && !item.Expire.After(time.Now()) {
+		return nil, false
+	}
+	sd := *item
+	f(&sd)
+	ms.cmap.Set(k, &sd)
+	return &sd, true
+}
+
+func (ms MemStore) syncRefresh(ctx context.Context, k string, w *StoreData) {
+	if ms.SyncRemote {
+		r := &WSRequest{
+			Expire: w.Expire.Unix(),
+			IP:     k,
+			Method: "Refresh",
+			TTL:    int64(w.TTL / time.Second),
+		}
+		enqueueSync(ctx, r)
+	}
+}
+
+// SyncRefresh update expire time of remote host store data.
+func (ms MemStore) SyncRefresh(k string, w *StoreData) bool {
+	_, ok := ms.update(k, false, func(sd *StoreData) {
+		sd.Expire = w.Expire
+		if w.TTL > 0 {
+			sd.TTL = w.TTL
+		}
+	})
+	return ok
 }
 
 // Items return all data from cmap store.
@@ -127,15 +537,13 @@ func (ms MemStore) Items() map[string]*StoreData {
 	return ms.cmap.Items()
 }
 
-// ItemsJSON return all data of json format.
+// ItemsJSON return all data of json format, expired data is deleted.
 func (ms MemStore) ItemsJSON() ([]byte, error) {
 	var sd []*StoreData
 	for _, item := range ms.Items() {
-		if item.Expire.Before(time.Now()) {
-			msg := fmt.Sprintf("ExpireData:%s", item.Key())
-			Log("info", msg, nil, nil)
-			if MainStore != nil {
-				MainStore.SyncDel(item.Key())
+		if now := time.Now(); !item.Expire.After(now) {
+			if deleted, ok := ms.popExpired(item.Key(), now); ok {
+				expiredData(deleted)
 			}
 		} else {
 			sd = append(sd, item)
@@ -153,29 +561,77 @@ func (ms MemStore) Count() int {
 type StoreData struct {
 	Expire time.Time
 	IP     net.IP
-	Data   string
+	// PrefixLen is prefix length of CIDR login, zero for IP address.
+	PrefixLen int
+	// PortFrom and PortTo are port range of CGNAT login, zero for whole IP address.
+	PortFrom int
+	PortTo   int
+	Data     string
+	TTL      time.Duration
+	// Created is LOGIN time, used for the limit of sliding expire.
+	Created time.Time
 }
 
 // UpdateExpire Update stored data of expire time.
 func (sd *StoreData) UpdateExpire() {
-	sd.Expire = time.Now().Add(StoreDataExpire)
+	ttl := sd.TTL
+	if ttl <= 0 {
+		ttl = StoreDataExpire
+	}
+	sd.Expire = time.Now().Add(ttl)
 }
 
 // Key return key string.
 func (sd *StoreData) Key() string {
-	return sd.IP.String()
+	return portKey(storeKey(sd.IP, sd.PrefixLen), sd.PortFrom, sd.PortTo)
 }
 
-func deleteExpireData(store Store) {
+func deleteExpireData(ctx context.Context, store Store) {
+	now := time.Now()
 	for _, item := range store.Items() {
-		if item.Expire.Before(time.Now()) {
-			msg := fmt.Sprintf("ExpireData:%s", item.Key())
-			Log("info", msg, nil, nil)
-			store.Del(item.Key())
+		if !item.Expire.Before(now) {
+			continue
+		}
+		if sd, ok := storeExpire(ctx, store, item, now); ok {
+			expiredData(sd)
 		}
 	}
 }
 
+// expiredData log sd deleted by expiry, and emit its expire event.
+func expiredData(sd *StoreData) {
+	msg := fmt.Sprintf("ExpireData:%s", sd.Key())
+	Log("info", msg, nil, nil)
+	storeEvent(hExpire, eventLocal, sd)
+	promExpiredTotal.Inc()
+}
+
+// storeProbeKey is key looked up by CheckStore, which is never stored.
+const storeProbeKey = "gowhoson.probe"
+
+// CheckStore return error when MainStore does not answer a delete and lookup
+// of a probe key in timeout, which is liveness of the server.
+func CheckStore(timeout time.Duration) error {
+	store := MainStore
+	if store == nil {
+		return errors.New("store not initialized")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		store.SyncDel(storeProbeKey)
+		store.Get(storeProbeKey)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-t.C:
+		return errors.Errorf("store does not respond in %v", timeout)
+	}
+}
+
 // RunExpireChecker Check expire for all cmap store data.
 func RunExpireChecker(ctx context.Context) {
 	t := time.NewTicker(ExpireCheckInterval)
@@ -187,16 +643,16 @@ func RunExpireChecker(ctx context.Context) {
 			return
 		case <-t.C:
 			if MainStore != nil {
-				deleteExpireData(MainStore)
+				deleteExpireData(ctx, MainStore)
 			}
 		}
 	}
 }
 
-// RunSyncRemote is sync data to remote grpc servers.
-func RunSyncRemote(ctx context.Context, hosts []string) {
-	defer close(syncChan)
-
+// RunSyncRemote is sync data to remote grpc servers of ServerConfig.SyncRemote,
+// the servers are read for each data to follow config reload. It is stopped by
+// ctx, and syncChan is left open for late store mutations of stopping servers.
+func RunSyncRemote(ctx context.Context) {
 	if Logger != nil {
 		logging.InjectLogField(context.Background(), "logger", Logger)
 	}
@@ -206,27 +662,35 @@ func RunSyncRemote(ctx context.Context, hosts []string) {
 		case <-ctx.Done():
 			Log("info", "RunSyncRemoteStop", nil, nil)
 			return
-		case req, ok := <-syncChan:
-			if !ok {
-				return
-			}
-			for _, h := range hosts {
-				if h != "" {
-					go execSyncRemote(req, h)
-				}
+		case sr := <-syncChan:
+			for _, h := range getServerConfig().syncHosts {
+				go execSyncRemote(sr, h)
 			}
 		}
 	}
 }
 
-func execSyncRemote(req *WSRequest, remotehost string) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+// execSyncRemote send sr to remotehost, trace context of the store mutation is
+// propagated in gRPC metadata.
+func execSyncRemote(sr *syncRequest, remotehost string) {
+	req := sr.req
+	ctx, span := startSpan(trace.ContextWithSpanContext(context.Background(), sr.span), "execSyncRemote",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("whoson.peer", remotehost),
+			attribute.String("whoson.sync.method", req.Method),
+			attribute.String("whoson.key", req.IP),
+		))
+	defer span.End()
+	ctx, cancel := context.WithTimeout(injectTrace(ctx), time.Second*5)
 	defer cancel()
 
 	conn, err := grpc.NewClient(remotehost,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
 	if err != nil {
+		observeSync(remotehost, err)
+		spanError(span, err)
 		Log("error", "execSyncRemote:Error", nil, err)
 		return
 	}
@@ -234,14 +698,24 @@ func execSyncRemote(req *WSRequest, remotehost string) {
 
 	client := NewSyncClient(conn)
 
+	var res *WSResponse
 	switch req.Method {
 	case "Set":
-		_, err = client.Set(ctx, req)
+		res, err = client.Set(ctx, req)
 		Log("debug", "execSyncRemote:Set", nil, nil)
 	case "Del":
-		_, err = client.Del(ctx, req)
+		res, err = client.Del(ctx, req)
 		Log("debug", "execSyncRemote:Del", nil, nil)
+	case "Expire":
+		res, err = client.Del(ctx, req)
+		Log("debug", "execSyncRemote:Expire", nil, nil)
+	case "Refresh":
+		res, err = client.Refresh(ctx, req)
+		Log("debug", "execSyncRemote:Refresh", nil, nil)
 	}
+	observeSync(remotehost, err)
+	spanError(span, err)
+	span.SetAttributes(attribute.Int("whoson.sync.rcode", int(res.GetRcode())))
 	if err != nil {
 		Log("error", "execSyncRemote:Error", nil, err)
 	}
@@ -1,6 +1,7 @@
 package whoson
 
 import (
+	"context"
 	"net"
 	"reflect"
 	"testing"
@@ -61,6 +62,174 @@ func TestMemStore_SetGetDel(t *testing.T) {
 	}
 }
 
+func TestMemStore_Refresh(t *testing.T) {
+	sd := newStoreData("value1")
+	sd.Expire = time.Now().Add(time.Minute)
+	store.Set("key1", sd)
+	defer store.Del("key1")
+
+	rs := store.(RefreshStore)
+	actual, err := rs.Refresh("key1", time.Hour)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if actual.TTL != time.Hour || actual.Expire.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("expected ttl %v, actual %v, expire %v", time.Hour, actual.TTL, actual.Expire)
+	}
+	if actual.Data != "value1" {
+		t.Fatalf("expected %v, actual %v", "value1", actual.Data)
+	}
+
+	if _, err := rs.Refresh("key2", 0); err == nil {
+		t.Fatalf("expected error for key2")
+	}
+}
+
+func TestMemStore_ExpiredOnAccess(t *testing.T) {
+	NewLogger("discard", "error")
+	ms := NewMemStore()
+	w := watchers.subscribe(&watchFilter{user: "user1"})
+	defer watchers.unsubscribe(w)
+	expectExpire := func(ip string) {
+		t.Helper()
+		if ev := <-w.events; ev.Event != hExpire || ev.IP != ip {
+			t.Fatalf("expected expire event of %s, actual %+v", ip, ev)
+		}
+		if n := ms.Count(); n != 0 {
+			t.Fatalf("expired data should be deleted, actual count %v", n)
+		}
+	}
+
+	ms.SyncSet("192.0.2.1", &StoreData{IP: net.ParseIP("192.0.2.1"), Data: "user1", Expire: time.Now().Add(-time.Second)})
+	if _, err := ms.Get("192.0.2.1"); err == nil {
+		t.Fatalf("expired data should not be found")
+	}
+	expectExpire("192.0.2.1")
+
+	ms.SyncSet("192.0.2.2", &StoreData{IP: net.ParseIP("192.0.2.2"), Data: "user1", Expire: time.Now().Add(-time.Second)})
+	if b, err := ms.ItemsJSON(); err != nil || string(b) != "null" {
+		t.Fatalf("expired data should not be dumped, actual %s %v", b, err)
+	}
+	expectExpire("192.0.2.2")
+}
+
+func TestQueryLogout_Expired(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	ctx := context.Background()
+	expired := func(ip string) {
+		MainStore.SyncSet(ip, &StoreData{IP: net.ParseIP(ip), Data: "user2", Expire: time.Now().Add(-time.Second)})
+	}
+
+	expired("192.0.2.3")
+	if sd, err := queryData(ctx, net.ParseIP("192.0.2.3"), 0); err == nil {
+		t.Fatalf("QUERY of expired data should be not found, actual %v", sd)
+	}
+	expired("192.0.2.4")
+	if sd, ok := logoutData(ctx, "192.0.2.4"); ok {
+		t.Fatalf("LOGOUT of expired data should be not found, actual %v", sd)
+	}
+	for _, k := range []string{"192.0.2.3", "192.0.2.4"} {
+		if _, ok := MainStore.Items()[k]; ok {
+			t.Fatalf("expired data %s should be deleted", k)
+		}
+	}
+}
+
+func TestMemStore_RefreshDelRace(t *testing.T) {
+	ms := NewMemStore().(MemStore)
+	for i := 0; i < 1000; i++ {
+		ms.Set("key1", newStoreData("value1"))
+		done := make(chan struct{})
+		go func() {
+			ms.Refresh("key1", time.Hour)
+			ms.SetExpire("key1", time.Now().Add(time.Hour))
+			ms.SyncRefresh("key1", &StoreData{Expire: time.Now().Add(time.Hour)})
+			close(done)
+		}()
+		ms.Del("key1")
+		<-done
+		if sd, err := ms.Get("key1"); err == nil {
+			t.Fatalf("deleted key1 should not be stored by refresh, actual %v", sd)
+		}
+	}
+}
+
+// plainStore hide optional Store APIs of MemStore.
+type plainStore struct {
+	Store
+}
+
+func TestStoreHelpers_PlainStore(t *testing.T) {
+	ps := plainStore{NewMemStore()}
+	if _, ok := Store(ps).(ContextStore); ok {
+		t.Fatalf("plainStore should not be ContextStore")
+	}
+	if _, ok := Store(ps).(RefreshStore); ok {
+		t.Fatalf("plainStore should not be RefreshStore")
+	}
+	if _, ok := Store(ps).(LookupStore); ok {
+		t.Fatalf("plainStore should not be LookupStore")
+	}
+	ctx := context.Background()
+	sd := newStoreData("user1")
+	sd.IP = net.ParseIP("192.0.2.1")
+	storeSet(ctx, ps, "192.0.2.1", sd)
+	if sd, err := storeRefresh(ctx, ps, "192.0.2.1", time.Hour); err != nil || sd.TTL != time.Hour {
+		t.Fatalf("expected ttl %v, actual %v %v", time.Hour, sd, err)
+	}
+	if sd, err := storeLookup(ps, net.ParseIP("192.0.2.1"), 1500); err != nil || sd.Data != "user1" {
+		t.Fatalf("expected user1, actual %v %v", sd, err)
+	}
+	if sds := storeFindByUser(ps, "user1"); len(sds) != 1 {
+		t.Fatalf("expected 1 data of user1, actual %v", sds)
+	}
+	expire := time.Now().Add(time.Minute)
+	if !storeSyncRefresh(ps, "192.0.2.1", &StoreData{Expire: expire}) {
+		t.Fatalf("192.0.2.1 should be refreshed")
+	}
+	if sd, err := ps.Get("192.0.2.1"); err != nil || !sd.Expire.Equal(expire) {
+		t.Fatalf("expected expire %v, actual %v %v", expire, sd, err)
+	}
+	storeSet(ctx, ps, "key1", newStoreData("value1"))
+	expire = time.Now().Add(2 * time.Minute)
+	if sd, err := storeSetExpire(ctx, ps, "key1", expire); err != nil || !sd.Expire.Equal(expire) {
+		t.Fatalf("expected expire %v, actual %v %v", expire, sd, err)
+	}
+	if !storeDel(ctx, ps, "key1") {
+		t.Fatalf("key1 should be deleted")
+	}
+}
+
+// blockedStore is Store which Get does not return until release is closed.
+type blockedStore struct {
+	Store
+	release chan struct{}
+}
+
+func (bs blockedStore) Get(k string) (*StoreData, error) {
+	<-bs.release
+	return bs.Store.Get(k)
+}
+
+func TestCheckStore(t *testing.T) {
+	defer func(s Store) { MainStore = s }(MainStore)
+	MainStore = NewMemStore()
+	if err := CheckStore(time.Second); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if n := MainStore.Count(); n != 0 {
+		t.Fatalf("probe key should not be stored, actual count %v", n)
+	}
+
+	bs := blockedStore{Store: NewMemStore(), release: make(chan struct{})}
+	defer close(bs.release)
+	MainStore = bs
+	if err := CheckStore(10 * time.Millisecond); err == nil {
+		t.Fatalf("expected error for blocked store")
+	}
+}
+
 func TestStoreData_UpdateExpire(t *testing.T) {
 	sd := newStoreData("test")
 	t1 := sd.Expire
@@ -70,6 +239,15 @@ func TestStoreData_UpdateExpire(t *testing.T) {
 	}
 }
 
+func TestStoreData_UpdateExpireTTL(t *testing.T) {
+	sd := newStoreData("test")
+	sd.TTL = 2 * time.Hour
+	sd.UpdateExpire()
+	if sd.Expire.Before(time.Now().Add(time.Hour)) {
+		t.Fatalf("ttl %v, UpdateExpire at %v", sd.TTL, sd.Expire)
+	}
+}
+
 func TestStoreData_Key(t *testing.T) {
 	expected := "10.0.0.1"
 	sd := newStoreData("test")
@@ -2,8 +2,12 @@ package whoson
 
 import (
 	"context"
+	"encoding/json"
 	"net"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ SyncServer = (*Sync)(nil)
@@ -13,22 +17,67 @@ type Sync struct {
 	UnimplementedSyncServer
 }
 
+// startSyncSpan start span of sync request from peer, as child of trace context in gRPC metadata.
+func startSyncSpan(ctx context.Context, name string, wreq *WSRequest) (context.Context, trace.Span) {
+	return startSpan(extractTrace(ctx), name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("whoson.key", wreq.IP)))
+}
+
 // Set sync to repliction servers
 func (s *Sync) Set(c context.Context, wreq *WSRequest) (*WSResponse, error) {
-	ip := net.ParseIP(wreq.IP)
-	req := &StoreData{
-		Expire: time.Unix(wreq.Expire, 0),
-		IP:     ip,
-		Data:   wreq.Data,
+	_, span := startSyncSpan(c, "Sync.Set", wreq)
+	defer span.End()
+	req, err := parseKey(wreq.IP)
+	if err != nil {
+		return &WSResponse{Msg: "NG", Rcode: 2}, nil
+	}
+	req.Expire = time.Unix(wreq.Expire, 0)
+	req.Data = wreq.Data
+	req.TTL = time.Duration(wreq.TTL) * time.Second
+	if wreq.Created > 0 {
+		req.Created = time.Unix(wreq.Created, 0)
 	}
-	MainStore.SyncSet(ip.String(), req)
+	MainStore.SyncSet(req.Key(), req)
+	storeEvent(hLogin, eventSync, req)
 	return &WSResponse{Msg: "OK", Rcode: 1}, nil
 }
 
-// Del delete to repliction servers
+// Del delete to repliction servers, as Expire event when Method is "Expire"
 func (s *Sync) Del(c context.Context, wreq *WSRequest) (*WSResponse, error) {
-	ip := net.ParseIP(wreq.IP)
-	if MainStore.SyncDel(ip.String()) {
+	_, span := startSyncSpan(c, "Sync.Del", wreq)
+	defer span.End()
+	key, err := parseKey(wreq.IP)
+	if err != nil {
+		return &WSResponse{Msg: "NG", Rcode: 2}, nil
+	}
+	if sd, ok := storeSyncPop(MainStore, key.Key()); ok {
+		event := hLogout
+		if wreq.Method == "Expire" {
+			event = hExpire
+		}
+		storeEvent(event, eventSync, sd)
+		return &WSResponse{Msg: "OK", Rcode: 1}, nil
+	}
+	return &WSResponse{Msg: "NG", Rcode: 2}, nil
+}
+
+// Refresh update expire time to repliction servers
+func (s *Sync) Refresh(c context.Context, wreq *WSRequest) (*WSResponse, error) {
+	_, span := startSyncSpan(c, "Sync.Refresh", wreq)
+	defer span.End()
+	key, err := parseKey(wreq.IP)
+	if err != nil {
+		return &WSResponse{Msg: "NG", Rcode: 2}, nil
+	}
+	req := &StoreData{
+		Expire: time.Unix(wreq.Expire, 0),
+		TTL:    time.Duration(wreq.TTL) * time.Second,
+	}
+	if storeSyncRefresh(MainStore, key.Key(), req) {
+		if sd, err := MainStore.Get(key.Key()); err == nil {
+			storeEvent(hRefresh, eventSync, sd)
+		}
 		return &WSResponse{Msg: "OK", Rcode: 1}, nil
 	}
 	return &WSResponse{Msg: "NG", Rcode: 2}, nil
@@ -42,3 +91,38 @@ func (s *Sync) Dump(c context.Context, wreq *WSDumpRequest) (*WSDumpResponse, er
 	}
 	return &WSDumpResponse{Msg: "OK", Rcode: 1, Json: jsonb}, nil
 }
+
+// Lookup dump data logged in by user
+func (s *Sync) Lookup(c context.Context, wreq *WSLookupRequest) (*WSDumpResponse, error) {
+	jsonb, err := json.Marshal(storeFindByUser(MainStore, wreq.Data))
+	if err != nil {
+		return &WSDumpResponse{Msg: "NG", Rcode: 2, Json: []byte("{}")}, nil
+	}
+	return &WSDumpResponse{Msg: "OK", Rcode: 1, Json: jsonb}, nil
+}
+
+// History dump login history of IP at Time, or of user from From to To
+func (s *Sync) History(c context.Context, wreq *WSHistoryRequest) (*WSDumpResponse, error) {
+	if MainHistory == nil {
+		return &WSDumpResponse{Msg: "history disabled", Rcode: 2, Json: []byte("{}")}, nil
+	}
+	var records []*HistoryRecord
+	var err error
+	if wreq.IP != "" {
+		ip := net.ParseIP(wreq.IP)
+		if ip == nil {
+			return &WSDumpResponse{Msg: "NG", Rcode: 2, Json: []byte("{}")}, nil
+		}
+		records, err = MainHistory.FindByIP(ip, time.Unix(wreq.Time, 0))
+	} else {
+		records, err = MainHistory.FindByUser(wreq.Data, time.Unix(wreq.From, 0), time.Unix(wreq.To, 0))
+	}
+	if err != nil {
+		return &WSDumpResponse{Msg: "NG", Rcode: 2, Json: []byte("{}")}, nil
+	}
+	jsonb, err := json.Marshal(records)
+	if err != nil {
+		return &WSDumpResponse{Msg: "NG", Rcode: 2, Json: []byte("{}")}, nil
+	}
+	return &WSDumpResponse{Msg: "OK", Rcode: 1, Json: jsonb}, nil
+}
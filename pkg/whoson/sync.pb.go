@@ -27,6 +27,8 @@ type WSRequest struct {
 	IP            string                 `protobuf:"bytes,2,opt,name=IP,proto3" json:"IP,omitempty"`
 	Data          string                 `protobuf:"bytes,3,opt,name=Data,proto3" json:"Data,omitempty"`
 	Method        string                 `protobuf:"bytes,4,opt,name=Method,proto3" json:"Method,omitempty"`
+	TTL           int64                  `protobuf:"varint,5,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	Created       int64                  `protobuf:"varint,6,opt,name=Created,proto3" json:"Created,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -89,6 +91,20 @@ func (x *WSRequest) GetMethod() string {
 	return ""
 }
 
+func (x *WSRequest) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+func (x *WSRequest) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
 type WSResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Rcode         int32                  `protobuf:"varint,1,opt,name=Rcode,proto3" json:"Rcode,omitempty"`
@@ -177,6 +193,126 @@ func (*WSDumpRequest) Descriptor() ([]byte, []int) {
 	return file_pkg_whoson_sync_proto_rawDescGZIP(), []int{2}
 }
 
+type WSLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          string                 `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSLookupRequest) Reset() {
+	*x = WSLookupRequest{}
+	mi := &file_pkg_whoson_sync_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSLookupRequest) ProtoMessage() {}
+
+func (x *WSLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_sync_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSLookupRequest.ProtoReflect.Descriptor instead.
+func (*WSLookupRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_sync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WSLookupRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+type WSHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IP            string                 `protobuf:"bytes,1,opt,name=IP,proto3" json:"IP,omitempty"`
+	Data          string                 `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	Time          int64                  `protobuf:"varint,3,opt,name=Time,proto3" json:"Time,omitempty"`
+	From          int64                  `protobuf:"varint,4,opt,name=From,proto3" json:"From,omitempty"`
+	To            int64                  `protobuf:"varint,5,opt,name=To,proto3" json:"To,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSHistoryRequest) Reset() {
+	*x = WSHistoryRequest{}
+	mi := &file_pkg_whoson_sync_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSHistoryRequest) ProtoMessage() {}
+
+func (x *WSHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_sync_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSHistoryRequest.ProtoReflect.Descriptor instead.
+func (*WSHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_sync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WSHistoryRequest) GetIP() string {
+	if x != nil {
+		return x.IP
+	}
+	return ""
+}
+
+func (x *WSHistoryRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *WSHistoryRequest) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *WSHistoryRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *WSHistoryRequest) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
 type WSDumpResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Rcode         int32                  `protobuf:"varint,1,opt,name=Rcode,proto3" json:"Rcode,omitempty"`
@@ -188,7 +324,7 @@ type WSDumpResponse struct {
 
 func (x *WSDumpResponse) Reset() {
 	*x = WSDumpResponse{}
-	mi := &file_pkg_whoson_sync_proto_msgTypes[3]
+	mi := &file_pkg_whoson_sync_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -200,7 +336,7 @@ func (x *WSDumpResponse) String() string {
 func (*WSDumpResponse) ProtoMessage() {}
 
 func (x *WSDumpResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_whoson_sync_proto_msgTypes[3]
+	mi := &file_pkg_whoson_sync_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -213,7 +349,7 @@ func (x *WSDumpResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WSDumpResponse.ProtoReflect.Descriptor instead.
 func (*WSDumpResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_whoson_sync_proto_rawDescGZIP(), []int{3}
+	return file_pkg_whoson_sync_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *WSDumpResponse) GetRcode() int32 {
@@ -241,25 +377,38 @@ var File_pkg_whoson_sync_proto protoreflect.FileDescriptor
 
 const file_pkg_whoson_sync_proto_rawDesc = "" +
 	"\n" +
-	"\x15pkg/whoson/sync.proto\x12\x06whoson\"_\n" +
+	"\x15pkg/whoson/sync.proto\x12\x06whoson\"\x8b\x01\n" +
 	"\tWSRequest\x12\x16\n" +
 	"\x06Expire\x18\x01 \x01(\x03R\x06Expire\x12\x0e\n" +
 	"\x02IP\x18\x02 \x01(\tR\x02IP\x12\x12\n" +
 	"\x04Data\x18\x03 \x01(\tR\x04Data\x12\x16\n" +
-	"\x06Method\x18\x04 \x01(\tR\x06Method\"4\n" +
+	"\x06Method\x18\x04 \x01(\tR\x06Method\x12\x10\n" +
+	"\x03TTL\x18\x05 \x01(\x03R\x03TTL\x12\x18\n" +
+	"\aCreated\x18\x06 \x01(\x03R\aCreated\"4\n" +
 	"\n" +
 	"WSResponse\x12\x14\n" +
 	"\x05Rcode\x18\x01 \x01(\x05R\x05Rcode\x12\x10\n" +
 	"\x03Msg\x18\x02 \x01(\tR\x03Msg\"\x0f\n" +
-	"\rWSDumpRequest\"L\n" +
+	"\rWSDumpRequest\"%\n" +
+	"\x0fWSLookupRequest\x12\x12\n" +
+	"\x04Data\x18\x01 \x01(\tR\x04Data\"n\n" +
+	"\x10WSHistoryRequest\x12\x0e\n" +
+	"\x02IP\x18\x01 \x01(\tR\x02IP\x12\x12\n" +
+	"\x04Data\x18\x02 \x01(\tR\x04Data\x12\x12\n" +
+	"\x04Time\x18\x03 \x01(\x03R\x04Time\x12\x12\n" +
+	"\x04From\x18\x04 \x01(\x03R\x04From\x12\x0e\n" +
+	"\x02To\x18\x05 \x01(\x03R\x02To\"L\n" +
 	"\x0eWSDumpResponse\x12\x14\n" +
 	"\x05Rcode\x18\x01 \x01(\x05R\x05Rcode\x12\x10\n" +
 	"\x03Msg\x18\x02 \x01(\tR\x03Msg\x12\x12\n" +
-	"\x04Json\x18\x03 \x01(\fR\x04Json2\x9f\x01\n" +
+	"\x04Json\x18\x03 \x01(\fR\x04Json2\xcf\x02\n" +
 	"\x04sync\x12.\n" +
 	"\x03Set\x12\x11.whoson.WSRequest\x1a\x12.whoson.WSResponse\"\x00\x12.\n" +
 	"\x03Del\x12\x11.whoson.WSRequest\x1a\x12.whoson.WSResponse\"\x00\x127\n" +
-	"\x04Dump\x12\x15.whoson.WSDumpRequest\x1a\x16.whoson.WSDumpResponse\"\x00B-Z+github.com/tai-ga/gowhoso/pkg/whoson;whosonb\x06proto3"
+	"\x04Dump\x12\x15.whoson.WSDumpRequest\x1a\x16.whoson.WSDumpResponse\"\x00\x122\n" +
+	"\aRefresh\x12\x11.whoson.WSRequest\x1a\x12.whoson.WSResponse\"\x00\x12;\n" +
+	"\x06Lookup\x12\x17.whoson.WSLookupRequest\x1a\x16.whoson.WSDumpResponse\"\x00\x12=\n" +
+	"\aHistory\x12\x18.whoson.WSHistoryRequest\x1a\x16.whoson.WSDumpResponse\"\x00B-Z+github.com/tai-ga/gowhoso/pkg/whoson;whosonb\x06proto3"
 
 var (
 	file_pkg_whoson_sync_proto_rawDescOnce sync.Once
@@ -273,22 +422,30 @@ func file_pkg_whoson_sync_proto_rawDescGZIP() []byte {
 	return file_pkg_whoson_sync_proto_rawDescData
 }
 
-var file_pkg_whoson_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_whoson_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_pkg_whoson_sync_proto_goTypes = []any{
-	(*WSRequest)(nil),      // 0: whoson.WSRequest
-	(*WSResponse)(nil),     // 1: whoson.WSResponse
-	(*WSDumpRequest)(nil),  // 2: whoson.WSDumpRequest
-	(*WSDumpResponse)(nil), // 3: whoson.WSDumpResponse
+	(*WSRequest)(nil),        // 0: whoson.WSRequest
+	(*WSResponse)(nil),       // 1: whoson.WSResponse
+	(*WSDumpRequest)(nil),    // 2: whoson.WSDumpRequest
+	(*WSLookupRequest)(nil),  // 3: whoson.WSLookupRequest
+	(*WSHistoryRequest)(nil), // 4: whoson.WSHistoryRequest
+	(*WSDumpResponse)(nil),   // 5: whoson.WSDumpResponse
 }
 var file_pkg_whoson_sync_proto_depIdxs = []int32{
 	0, // 0: whoson.sync.Set:input_type -> whoson.WSRequest
 	0, // 1: whoson.sync.Del:input_type -> whoson.WSRequest
 	2, // 2: whoson.sync.Dump:input_type -> whoson.WSDumpRequest
-	1, // 3: whoson.sync.Set:output_type -> whoson.WSResponse
-	1, // 4: whoson.sync.Del:output_type -> whoson.WSResponse
-	3, // 5: whoson.sync.Dump:output_type -> whoson.WSDumpResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
+	0, // 3: whoson.sync.Refresh:input_type -> whoson.WSRequest
+	3, // 4: whoson.sync.Lookup:input_type -> whoson.WSLookupRequest
+	4, // 5: whoson.sync.History:input_type -> whoson.WSHistoryRequest
+	1, // 6: whoson.sync.Set:output_type -> whoson.WSResponse
+	1, // 7: whoson.sync.Del:output_type -> whoson.WSResponse
+	5, // 8: whoson.sync.Dump:output_type -> whoson.WSDumpResponse
+	1, // 9: whoson.sync.Refresh:output_type -> whoson.WSResponse
+	5, // 10: whoson.sync.Lookup:output_type -> whoson.WSDumpResponse
+	5, // 11: whoson.sync.History:output_type -> whoson.WSDumpResponse
+	6, // [6:12] is the sub-list for method output_type
+	0, // [0:6] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -305,7 +462,7 @@ func file_pkg_whoson_sync_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_whoson_sync_proto_rawDesc), len(file_pkg_whoson_sync_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
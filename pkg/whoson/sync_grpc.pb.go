@@ -19,9 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Sync_Set_FullMethodName  = "/whoson.sync/Set"
-	Sync_Del_FullMethodName  = "/whoson.sync/Del"
-	Sync_Dump_FullMethodName = "/whoson.sync/Dump"
+	Sync_Set_FullMethodName     = "/whoson.sync/Set"
+	Sync_Del_FullMethodName     = "/whoson.sync/Del"
+	Sync_Dump_FullMethodName    = "/whoson.sync/Dump"
+	Sync_Refresh_FullMethodName = "/whoson.sync/Refresh"
+	Sync_Lookup_FullMethodName  = "/whoson.sync/Lookup"
+	Sync_History_FullMethodName = "/whoson.sync/History"
 )
 
 // SyncClient is the client API for Sync service.
@@ -31,6 +34,9 @@ type SyncClient interface {
 	Set(ctx context.Context, in *WSRequest, opts ...grpc.CallOption) (*WSResponse, error)
 	Del(ctx context.Context, in *WSRequest, opts ...grpc.CallOption) (*WSResponse, error)
 	Dump(ctx context.Context, in *WSDumpRequest, opts ...grpc.CallOption) (*WSDumpResponse, error)
+	Refresh(ctx context.Context, in *WSRequest, opts ...grpc.CallOption) (*WSResponse, error)
+	Lookup(ctx context.Context, in *WSLookupRequest, opts ...grpc.CallOption) (*WSDumpResponse, error)
+	History(ctx context.Context, in *WSHistoryRequest, opts ...grpc.CallOption) (*WSDumpResponse, error)
 }
 
 type syncClient struct {
@@ -71,6 +77,36 @@ func (c *syncClient) Dump(ctx context.Context, in *WSDumpRequest, opts ...grpc.C
 	return out, nil
 }
 
+func (c *syncClient) Refresh(ctx context.Context, in *WSRequest, opts ...grpc.CallOption) (*WSResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSResponse)
+	err := c.cc.Invoke(ctx, Sync_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *syncClient) Lookup(ctx context.Context, in *WSLookupRequest, opts ...grpc.CallOption) (*WSDumpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSDumpResponse)
+	err := c.cc.Invoke(ctx, Sync_Lookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *syncClient) History(ctx context.Context, in *WSHistoryRequest, opts ...grpc.CallOption) (*WSDumpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSDumpResponse)
+	err := c.cc.Invoke(ctx, Sync_History_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SyncServer is the server API for Sync service.
 // All implementations must embed UnimplementedSyncServer
 // for forward compatibility.
@@ -78,6 +114,9 @@ type SyncServer interface {
 	Set(context.Context, *WSRequest) (*WSResponse, error)
 	Del(context.Context, *WSRequest) (*WSResponse, error)
 	Dump(context.Context, *WSDumpRequest) (*WSDumpResponse, error)
+	Refresh(context.Context, *WSRequest) (*WSResponse, error)
+	Lookup(context.Context, *WSLookupRequest) (*WSDumpResponse, error)
+	History(context.Context, *WSHistoryRequest) (*WSDumpResponse, error)
 	mustEmbedUnimplementedSyncServer()
 }
 
@@ -97,6 +136,15 @@ func (UnimplementedSyncServer) Del(context.Context, *WSRequest) (*WSResponse, er
 func (UnimplementedSyncServer) Dump(context.Context, *WSDumpRequest) (*WSDumpResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Dump not implemented")
 }
+func (UnimplementedSyncServer) Refresh(context.Context, *WSRequest) (*WSResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedSyncServer) Lookup(context.Context, *WSLookupRequest) (*WSDumpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lookup not implemented")
+}
+func (UnimplementedSyncServer) History(context.Context, *WSHistoryRequest) (*WSDumpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
 func (UnimplementedSyncServer) mustEmbedUnimplementedSyncServer() {}
 func (UnimplementedSyncServer) testEmbeddedByValue()              {}
 
@@ -172,6 +220,60 @@ func _Sync_Dump_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Sync_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sync_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncServer).Refresh(ctx, req.(*WSRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sync_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sync_Lookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncServer).Lookup(ctx, req.(*WSLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sync_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SyncServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sync_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SyncServer).History(ctx, req.(*WSHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Sync_ServiceDesc is the grpc.ServiceDesc for Sync service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,6 +293,18 @@ var Sync_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Dump",
 			Handler:    _Sync_Dump_Handler,
 		},
+		{
+			MethodName: "Refresh",
+			Handler:    _Sync_Refresh_Handler,
+		},
+		{
+			MethodName: "Lookup",
+			Handler:    _Sync_Lookup_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _Sync_History_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/whoson/sync.proto",
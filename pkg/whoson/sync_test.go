@@ -0,0 +1,52 @@
+package whoson
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSync_DelEvent(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	var tests = []struct {
+		method   string
+		expire   time.Time
+		expected string
+	}{
+		{"Del", time.Now().Add(time.Minute), hLogout},
+		{"Expire", time.Now().Add(-time.Second), hExpire},
+	}
+	w := watchers.subscribe(&watchFilter{user: "syncuser"})
+	defer watchers.unsubscribe(w)
+	s := &Sync{}
+	for _, tt := range tests {
+		MainStore.SyncSet("198.18.10.1", &StoreData{IP: net.ParseIP("198.18.10.1"), Data: "syncuser", Expire: tt.expire})
+		res, err := s.Del(context.Background(), &WSRequest{IP: "198.18.10.1", Method: tt.method})
+		if err != nil || res.Rcode != 1 {
+			t.Fatalf("%s should delete data, actual %v %v", tt.method, res, err)
+		}
+		if ev := <-w.events; ev.Event != tt.expected || ev.Source != eventSync {
+			t.Fatalf("%s expected %s event, actual %+v", tt.method, tt.expected, ev)
+		}
+	}
+	if res, _ := s.Del(context.Background(), &WSRequest{IP: "198.18.10.1", Method: "Expire"}); res.Rcode != 2 {
+		t.Fatalf("deleted data should not be deleted again, actual %v", res)
+	}
+}
+
+func TestMemStore_Expire(t *testing.T) {
+	ms := NewMemStore().(MemStore)
+	now := time.Now()
+	ms.SyncSet("192.0.2.1", &StoreData{IP: net.ParseIP("192.0.2.1"), Expire: now.Add(time.Minute)})
+	if _, ok := ms.expire(context.Background(), "192.0.2.1", now); ok {
+		t.Fatalf("refreshed data should not be expired")
+	}
+	if sd, ok := ms.expire(context.Background(), "192.0.2.1", now.Add(2*time.Minute)); !ok || sd.Key() != "192.0.2.1" {
+		t.Fatalf("expired data should be deleted, actual %v %v", sd, ok)
+	}
+	if n := ms.Count(); n != 0 {
+		t.Fatalf("expected count 0, actual %v", n)
+	}
+}
@@ -2,27 +2,39 @@ package whoson
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-// TCPServer hold information for tcp server.
+// ErrServerClosed is returned by Serve after Shutdown.
+var ErrServerClosed = errors.New("gowhoson: Server closed")
+
+// TCPServer hold information for tcp or unix server.
 type TCPServer struct {
-	listener *net.TCPListener
-	timeOut  time.Duration
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closing  atomic.Bool
+	done     chan struct{}
+	stats    *listenerStats
 	wg       *sync.WaitGroup
 	Addr     string
+	// TLSConfig enables TLS when set.
+	TLSConfig *tls.Config
 }
 
 // NewTCPServer return new TCPServer struct pointer.
 func NewTCPServer() *TCPServer {
 	return &TCPServer{
-		timeOut: SessionTimeOut,
-		wg:      &sync.WaitGroup{},
+		conns: make(map[net.Conn]bool),
+		done:  make(chan struct{}),
+		wg:    &sync.WaitGroup{},
 	}
 }
 
@@ -33,6 +45,21 @@ func ServeTCP(l *net.TCPListener) error {
 	return s.ServeTCP(l)
 }
 
+// ServeTCPTLS is start tcp server serve with TLS.
+func ServeTCPTLS(l *net.TCPListener, config *tls.Config) error {
+	s := NewTCPServer()
+	s.listener = l
+	s.TLSConfig = config
+	return s.ServeTCP(l)
+}
+
+// ServeUnix is start unix server serve.
+func ServeUnix(l *net.UnixListener) error {
+	s := NewTCPServer()
+	s.listener = l
+	return s.Serve(l)
+}
+
 // ListenAndServe simple start tcp server.
 func (s *TCPServer) ListenAndServe() error {
 	var addrudp net.TCPAddr
@@ -65,6 +92,11 @@ func (s *TCPServer) ListenAndServe() error {
 
 // ServeTCP is start tcp server serve
 func (s *TCPServer) ServeTCP(l *net.TCPListener) error {
+	return s.Serve(l)
+}
+
+// Serve is start tcp or unix server serve
+func (s *TCPServer) Serve(l net.Listener) error {
 	var err error
 	NewMainStore()
 	NewLogger("stdout", "warn")
@@ -75,9 +107,12 @@ func (s *TCPServer) ServeTCP(l *net.TCPListener) error {
 
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	Log("info", "TCPServerStart", nil, nil)
+	s.mu.Lock()
 	if s.listener == nil {
 		s.listener = l
 	}
+	s.mu.Unlock()
+	s.stats = newListenerStats(s.listener.Addr())
 	for {
 		select {
 		case <-ctx.Done():
@@ -85,35 +120,141 @@ func (s *TCPServer) ServeTCP(l *net.TCPListener) error {
 			goto DONE
 		default:
 		}
-		conn, err := s.listener.Accept()
-		if err != nil {
+		conn, aerr := s.listener.Accept()
+		if aerr != nil {
+			err = aerr
+			if s.closing.Load() {
+				err = ErrServerClosed
+			}
 			goto DONE
 		}
+		if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok && !isTrustedProxy(addr) && !allowSource(addr.IP) {
+			conn.Close()
+			continue
+		}
+		if err := setKeepAlive(conn, getServerConfig().TCPKeepAlive); err != nil {
+			Log("debug", "setKeepAlive:Error", nil, err)
+		}
+
+		ip, ok := s.acceptConn(conn)
+		if !ok {
+			continue
+		}
 
 		s.wg.Add(1)
-		go s.startSession(ctx, conn)
+		go s.startSession(ctx, conn, ip)
 	}
 DONE:
 	ctxCancel()
 	s.wait()
+	close(s.done)
 	Log("info", "TCPServerStop", nil, nil)
 	return err
 }
 
-func (s *TCPServer) startSession(ctx context.Context, conn net.Conn) {
+// Shutdown stop accepting connections, and wait for active sessions to finish
+// their current command. Idle sessions are closed at once. When ctx is done
+// before all sessions finished, remaining connections are closed and ctx error
+// is returned.
+func (s *TCPServer) Shutdown(ctx context.Context) error {
+	s.closing.Store(true)
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.listener.Close()
+	for conn, active := range s.conns {
+		if !active {
+			conn.SetReadDeadline(time.Now())
+		}
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *TCPServer) trackConn(conn net.Conn, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		s.conns[conn] = false
+	} else {
+		delete(s.conns, conn)
+	}
+}
+
+// setActive mark conn is processing a command, active conn is not
+// interrupted by Shutdown.
+func (s *TCPServer) setActive(conn net.Conn, active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns[conn] = active
+}
+
+// waitRequest wait for the first byte of request in idle timeout, then
+// apply read timeout to the request.
+func (s *TCPServer) waitRequest(ses *Session, raw net.Conn) bool {
+	if _, err := ses.tp.R.Peek(1); err != nil {
+		Log("debug", "startSession:Wait", ses, err)
+		return false
+	}
+	s.setActive(raw, true)
+	if err := raw.SetDeadline(time.Now().Add(getServerConfig().ReadTimeout())); err != nil {
+		Log("debug", "startSession:Error", ses, err)
+		return false
+	}
+	return true
+}
+
+// startSession serve conn, which is counted for ip by acceptConn.
+func (s *TCPServer) startSession(ctx context.Context, conn net.Conn, ip net.IP) {
+	raw := conn
+	s.trackConn(raw, true)
+	defer s.trackConn(raw, false)
 	defer func() {
 		conn.Close()
+		tcpConns.release(ip)
 		s.wg.Done()
 		expConnectsTCPCurrent.Add(-1)
+		s.stats.disconnect()
 	}()
 
 	expConnectsTCPTotal.Add(1)
 	expConnectsTCPCurrent.Add(1)
+	s.stats.connect()
+	pconn, err := s.prepareConn(ctx, conn)
+	if err != nil {
+		if err != errDropped {
+			Log("warn", "startSession:Error", nil, err)
+		}
+		return
+	}
+	conn = pconn
 	ses, err := NewSessionTCP(s, conn)
 	if err != nil {
 		expErrorsTotal.Add(1)
 		Log("error", "Session failed", ses, err)
 	}
+	if ip == nil {
+		cip, ok := acquireClientIP(ses, raw, conn)
+		if !ok {
+			return
+		}
+		if cip != nil {
+			defer tcpConns.releaseIP(cip)
+		}
+	}
 	Log("debug", "Session start", ses, nil)
 	for {
 		select {
@@ -123,15 +264,40 @@ func (s *TCPServer) startSession(ctx context.Context, conn net.Conn) {
 		default:
 		}
 
-		if err := conn.SetDeadline(time.Now().Add(s.timeOut)); err != nil {
+		if err := conn.SetDeadline(time.Now().Add(getServerConfig().IdleTimeout())); err != nil {
 			Log("debug", "startSession:Error", ses, err)
 			return
 		}
+		// checked after the deadline is set, not to overwrite the deadline by Shutdown.
+		if s.closing.Load() {
+			return
+		}
 
-		if !ses.startHandler() {
+		if !s.waitRequest(ses, raw) || !ses.startHandler() {
 			return
 		}
+		s.setActive(raw, false)
+	}
+}
+
+// prepareConn read PROXY protocol header, and handshake TLS.
+func (s *TCPServer) prepareConn(ctx context.Context, conn net.Conn) (net.Conn, error) {
+	timeout := getServerConfig().ReadTimeout()
+	conn, err := acceptProxy(conn, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if s.TLSConfig == nil {
+		return conn, nil
+	}
+	tconn := tls.Server(conn, s.TLSConfig)
+	if err := tconn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if err := tconn.HandshakeContext(ctx); err != nil {
+		return nil, errors.Wrap(err, "TLS handshake")
 	}
+	return tconn, nil
 }
 
 // wait causes the caller to block until all active Whoson sessions have finished
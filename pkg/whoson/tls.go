@@ -0,0 +1,102 @@
+package whoson
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// TLSCertificate hold certificate and key pair, which can be reloaded.
+type TLSCertificate struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewTLSCertificate return new TLSCertificate struct pointer loaded from files.
+func NewTLSCertificate(certFile string, keyFile string) (*TLSCertificate, error) {
+	c := &TLSCertificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload load certificate and key files again, the old pair is kept on error.
+func (c *TLSCertificate) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "TLS certificate load error")
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate return current certificate, used for tls.Config.
+func (c *TLSCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// GetClientCertificate return current certificate, used for tls.Config.
+func (c *TLSCertificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "TLS CA load error")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.Errorf("TLS CA load error: no certificate in %s", file)
+	}
+	return pool, nil
+}
+
+// NewServerTLSConfig return tls.Config for TCP server, client certificates are
+// required and verified when clientCAFile is set.
+func NewServerTLSConfig(cert *TLSCertificate, clientCAFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cert.GetCertificate,
+	}
+	if clientCAFile != "" {
+		pool, err := loadCertPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// NewClientTLSConfig return tls.Config for Client, system roots are used when
+// caFile is empty, and client certificate is sent when certFile is set.
+func NewClientTLSConfig(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := NewTLSCertificate(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = cert.GetClientCertificate
+	}
+	return config, nil
+}
@@ -0,0 +1,142 @@
+package whoson
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert create certificate signed by parent, self-signed if parent is nil.
+func newTestCert(t *testing.T, dir, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer := &testCert{cert: tmpl, key: key}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer = parent
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer.cert, &key.PublicKey, signer.key)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return &testCert{cert: cert, key: key}
+}
+
+func startTLSServer(t *testing.T, dir string, clientCA string) (*net.TCPListener, chan error) {
+	cert, err := NewTLSCertificate(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	config, err := NewServerTLSConfig(cert, clientCA)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- ServeTCPTLS(l, config)
+	}()
+	return l, done
+}
+
+func TestServeTCPTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	newTestCert(t, dir, "server", ca)
+	newTestCert(t, dir, "client", ca)
+
+	l, done := startTLSServer(t, dir, filepath.Join(dir, "ca.crt"))
+	defer func() {
+		l.Close()
+		<-done
+	}()
+
+	config, err := NewClientTLSConfig(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	client, err := DialTLS(l.Addr().String(), config)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	r, err := client.Query("192.0.2.1")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if r.String() != "-Not Logged in" {
+		t.Fatalf("expected -Not Logged in, actual %v", r.String())
+	}
+	client.Quit()
+
+	config, _ = NewClientTLSConfig(filepath.Join(dir, "ca.crt"), "", "")
+	if client, err = DialTLS(l.Addr().String(), config); err == nil {
+		_, err = client.Query("192.0.2.1")
+		client.Close()
+	}
+	if err == nil {
+		t.Fatalf("expected error without client certificate")
+	}
+}
+
+func TestTLSCertificate_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	newTestCert(t, dir, "server", ca)
+
+	cert, err := NewTLSCertificate(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	old, _ := cert.GetCertificate(nil)
+
+	newTestCert(t, dir, "server", ca)
+	if err := cert.Reload(); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if c, _ := cert.GetCertificate(nil); c == old {
+		t.Fatalf("expected reloaded certificate")
+	}
+
+	os.WriteFile(filepath.Join(dir, "server.crt"), []byte("broken"), 0600)
+	if err := cert.Reload(); err == nil {
+		t.Fatalf("expected error for broken certificate")
+	}
+	if c, _ := cert.GetCertificate(nil); c == old || c == nil {
+		t.Fatalf("expected previous certificate kept")
+	}
+}
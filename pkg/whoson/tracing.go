@@ -0,0 +1,138 @@
+package whoson
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	tracerName = "github.com/tai-ga/gowhoson/pkg/whoson"
+
+	// TracingOTLP export spans to OTLP gRPC endpoint URL of TracingEndpoint,
+	// or of OTEL_EXPORTER_OTLP_ENDPOINT when TracingEndpoint is empty.
+	TracingOTLP = "otlp"
+	// TracingFile write spans as JSON to file path of TracingEndpoint.
+	TracingFile = "file"
+)
+
+// InitTracing set global TracerProvider exporting spans by Tracing of config,
+// and W3C trace context propagator. Spans are not recorded when Tracing is empty.
+// The returned function flushes spans and closes the exporter.
+func InitTracing(ctx context.Context, config *ServerConfig) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	var err error
+	switch config.Tracing {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case TracingOTLP:
+		var opts []otlptracegrpc.Option
+		if config.TracingEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(config.TracingEndpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case TracingFile:
+		file, err = os.OpenFile(config.TracingEndpoint, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, errors.Wrap(err, "tracing file open error")
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return nil, errors.Errorf("tracing %q not supported", config.Tracing)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "tracing exporter error")
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "gowhoson"),
+			attribute.String("service.instance.id", strconv.Itoa(config.ServerID)),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if file != nil {
+			file.Close()
+		}
+		return err
+	}, nil
+}
+
+// startSpan start span of name with tracer of global TracerProvider.
+func startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// startStoreSpan start span of store mutation of key k.
+func startStoreSpan(ctx context.Context, name string, k string) (context.Context, trace.Span) {
+	return startSpan(ctx, name, trace.WithAttributes(attribute.String("whoson.key", k)))
+}
+
+// spanError record err to span, and set error status.
+func spanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// metadataCarrier adapt gRPC metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if v := metadata.MD(mc).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// injectTrace return ctx with trace context of ctx in outgoing gRPC metadata.
+func injectTrace(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// extractTrace return ctx with remote trace context of incoming gRPC metadata.
+func extractTrace(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
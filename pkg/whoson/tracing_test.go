@@ -0,0 +1,132 @@
+package whoson
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// tracedSpan hold fields of span written by file exporter.
+type tracedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		TraceID string
+		SpanID  string
+		Remote  bool
+	}
+}
+
+// initFileTracing set global TracerProvider writing spans to file, and return
+// function to read the spans, which resets the global TracerProvider.
+func initFileTracing(t *testing.T) func() map[string]tracedSpan {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := InitTracing(context.Background(), &ServerConfig{Tracing: TracingFile, TracingEndpoint: file})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	return func() map[string]tracedSpan {
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		defer f.Close()
+		spans := make(map[string]tracedSpan)
+		dec := json.NewDecoder(f)
+		for {
+			var s tracedSpan
+			if err := dec.Decode(&s); err == io.EOF {
+				return spans
+			} else if err != nil {
+				t.Fatalf("Error %v", err)
+			}
+			spans[s.Name] = s
+		}
+	}
+}
+
+func TestInitTracing(t *testing.T) {
+	shutdown, err := InitTracing(context.Background(), &ServerConfig{})
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("disabled tracing should not fail, actual %v", err)
+	}
+	if _, err := InitTracing(context.Background(), &ServerConfig{Tracing: "jaeger"}); err == nil {
+		t.Fatalf("unsupported tracing should fail")
+	}
+	if _, err := InitTracing(context.Background(), &ServerConfig{Tracing: TracingFile, TracingEndpoint: t.TempDir()}); err == nil {
+		t.Fatalf("tracing to directory should fail")
+	}
+}
+
+func TestTracing_Propagation(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	g := grpc.NewServer()
+	RegisterSyncServer(g, &Sync{})
+	go g.Serve(lis)
+	defer g.Stop()
+
+	readSpans := initFileTracing(t)
+	ctx, span := startSpan(context.Background(), "test")
+	if md, _ := metadata.FromOutgoingContext(injectTrace(ctx)); len(md.Get("traceparent")) != 1 {
+		t.Fatalf("traceparent should be injected, actual %v", md)
+	}
+	loginData(ctx, &StoreData{IP: net.ParseIP("198.18.9.1"), Data: "traceuser"}, 0)
+	execSyncRemote(&syncRequest{
+		req:  &WSRequest{Method: "Set", IP: "198.18.9.2", Data: "traceuser", Expire: time.Now().Add(time.Minute).Unix()},
+		span: span.SpanContext(),
+	}, lis.Addr().String())
+	span.End()
+	spans := readSpans()
+	defer logoutData(context.Background(), "198.18.9.1")
+	defer MainStore.SyncDel("198.18.9.2")
+
+	traceID := spans["test"].SpanContext.TraceID
+	var expected = []struct {
+		name   string
+		parent string
+		remote bool
+	}{
+		{"MemStore.Set", "test", false},
+		{"execSyncRemote", "test", false},
+		{"Sync.Set", "execSyncRemote", true},
+	}
+	for _, tt := range expected {
+		s, ok := spans[tt.name]
+		if !ok {
+			t.Fatalf("span %s not found in %v", tt.name, spans)
+		}
+		if s.SpanContext.TraceID != traceID || s.Parent.SpanID != spans[tt.parent].SpanContext.SpanID || s.Parent.Remote != tt.remote {
+			t.Fatalf("span %s should be child of %s, actual %+v", tt.name, tt.parent, s)
+		}
+	}
+	if _, err := MainStore.Get("198.18.9.2"); err != nil {
+		t.Fatalf("synced data should be stored, %v", err)
+	}
+}
@@ -6,14 +6,19 @@ import (
 	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-// UDPServer hold information for udp server.
+// UDPServer hold information for udp or unixgram server.
 type UDPServer struct {
-	conn    *net.UDPConn
+	mu      sync.Mutex
+	conn    net.PacketConn
+	closing atomic.Bool
+	done    chan struct{}
+	stats   *listenerStats
 	bp      *BufferPool
 	queue   chan interface{}
 	workers []*Worker
@@ -27,6 +32,7 @@ func NewUDPServer() *UDPServer {
 	return &UDPServer{
 		bp:      NewBufferPool(),
 		queue:   make(chan interface{}, maxQueues),
+		done:    make(chan struct{}),
 		timeOut: SessionTimeOut,
 		wg:      &sync.WaitGroup{},
 	}
@@ -43,6 +49,13 @@ func ServeUDP(c *net.UDPConn) error {
 	return s.ServeUDP(c)
 }
 
+// ServeUnixgram is start unixgram server serve.
+func ServeUnixgram(c *net.UnixConn) error {
+	s := NewUDPServer()
+	s.conn = c
+	return s.ServePacket(c)
+}
+
 // ListenAndServe simple start udp server.
 func (s *UDPServer) ListenAndServe() error {
 	var addrudp net.UDPAddr
@@ -75,6 +88,11 @@ func (s *UDPServer) ListenAndServe() error {
 
 // ServeUDP is start udp server serve.
 func (s *UDPServer) ServeUDP(c *net.UDPConn) error {
+	return s.ServePacket(c)
+}
+
+// ServePacket is start udp or unixgram server serve.
+func (s *UDPServer) ServePacket(c net.PacketConn) error {
 	var err error
 
 	NewMainStore()
@@ -84,9 +102,17 @@ func (s *UDPServer) ServeUDP(c *net.UDPConn) error {
 		return errors.Wrap(err, "IDGenerator failed")
 	}
 
+	s.mu.Lock()
 	if s.conn == nil {
 		s.conn = c
 	}
+	s.mu.Unlock()
+	s.stats = newListenerStats(s.conn.LocalAddr())
+	if addr := s.conn.LocalAddr(); addr != nil {
+		label := listenerLabel(addr)
+		udpQueues.add(label, s.queue)
+		defer udpQueues.remove(label)
+	}
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	maxWorkers := runtime.NumCPU()
@@ -104,16 +130,40 @@ func (s *UDPServer) ServeUDP(c *net.UDPConn) error {
 	err = s.startSession(ctx)
 	ctxCancel()
 	s.wg.Wait()
+	close(s.done)
 	return err
 }
 
+// Shutdown stop reading packets, and wait for workers to answer queued
+// packets, then close the connection. When ctx is done before the queue is
+// drained, the connection is closed and ctx error is returned.
+func (s *UDPServer) Shutdown(ctx context.Context) error {
+	s.closing.Store(true)
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	conn.SetReadDeadline(time.Now())
+
+	select {
+	case <-s.done:
+		conn.Close()
+		return nil
+	case <-ctx.Done():
+		conn.Close()
+		return ctx.Err()
+	}
+}
+
 func (s *UDPServer) getBuffer() *Buffer {
 	return s.bp.Get()
 }
 
 func (s *UDPServer) startSession(ctx context.Context) error {
 	var n int
-	var a *net.UDPAddr
+	var a net.Addr
 	var err error
 	for {
 		select {
@@ -123,20 +173,33 @@ func (s *UDPServer) startSession(ctx context.Context) error {
 		default:
 		}
 
-		if err := s.conn.SetDeadline(time.Now().Add(s.timeOut)); err != nil {
+		if err := s.conn.SetReadDeadline(time.Now().Add(s.timeOut)); err != nil {
 			err = errors.Wrap(err, "Can't set appropriate deadline!")
 			return err
 		}
+		// checked after the deadline is set, not to overwrite the deadline by Shutdown.
+		if s.closing.Load() {
+			err = ErrServerClosed
+			goto DONE
+		}
 
 		b := s.getBuffer()
-		n, a, err = s.conn.ReadFromUDP(b.buf)
+		n, a, err = s.conn.ReadFrom(b.buf)
 		if err != nil {
 			if opError, ok := err.(*net.OpError); ok && opError.Timeout() {
 				b.Free()
+				if s.closing.Load() {
+					err = ErrServerClosed
+					goto DONE
+				}
 				continue
 			}
 			goto DONE
 		}
+		if addr, ok := a.(*net.UDPAddr); ok && !allowSource(addr.IP) {
+			b.Free()
+			continue
+		}
 		b.count = n
 		ses, err := NewSessionUDP(s.conn, a, b)
 		if err != nil {
@@ -165,20 +228,35 @@ func (w *Worker) Run(ctx context.Context) {
 		case v := <-w.s.queue:
 			w.work(v)
 		case <-ctx.Done():
+			w.drain()
 			Log("info", "UDPServerWorkerStop", nil, nil)
 			return
 		}
 	}
 }
 
+// drain process sessions left in queue.
+func (w *Worker) drain() {
+	for {
+		select {
+		case v := <-w.s.queue:
+			w.work(v)
+		default:
+			return
+		}
+	}
+}
+
 func (w *Worker) work(v interface{}) {
 	if ses, ok := v.(*Session); ok {
 		defer func() {
 			ses.close()
 			expConnectsUDPCurrent.Add(-1)
+			w.s.stats.disconnect()
 		}()
 		expConnectsUDPTotal.Add(1)
 		expConnectsUDPCurrent.Add(1)
+		w.s.stats.connect()
 		ses.startHandler()
 	} else {
 		panic(v)
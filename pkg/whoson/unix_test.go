@@ -0,0 +1,74 @@
+package whoson
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeUnix(t *testing.T) {
+	NewLogger("discard", "error")
+	err := SetServerConfig(&ServerConfig{
+		ACL:        []ACLRule{{Network: "192.0.2.0/24", Methods: []string{"ALL"}}},
+		RateLimits: []RateLimit{{Methods: []string{"LOGIN", "QUERY"}, Rate: 0.001, Burst: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer SetServerConfig(nil)
+
+	dir := t.TempDir()
+	stream := filepath.Join(dir, "whoson.sock")
+	dgram := filepath.Join(dir, "whoson.dgram")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: stream, Net: "unix"})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	c, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: dgram, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	done := make(chan error, 2)
+	go func() { done <- ServeUnix(l) }()
+	go func() { done <- ServeUnixgram(c) }()
+	defer func() {
+		l.Close()
+		c.Close()
+		<-done
+		<-done
+	}()
+
+	var tests = []struct {
+		mode     string
+		addr     string
+		expected string
+	}{
+		{"unix", stream, "+LOGIN OK"},
+		{"unixgram", dgram, "+LOGIN OK"},
+	}
+	for _, tt := range tests {
+		client, err := Dial(tt.mode, tt.addr)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		r, err := client.Login("198.51.100.1", "user-"+tt.mode)
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if r.String() != tt.expected {
+			t.Fatalf("expected %v, actual %v", tt.expected, r.String())
+		}
+		r, err = client.Query("198.51.100.1")
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if r.String() != "+user-"+tt.mode {
+			t.Fatalf("expected +user-%v, actual %v", tt.mode, r.String())
+		}
+		// unix socket clients are not checked by ACL and RateLimits.
+		if r, err = client.Query("198.51.100.1"); err != nil || r.String() != "+user-"+tt.mode {
+			t.Fatalf("expected +user-%v without rate limit, actual %v %v", tt.mode, r, err)
+		}
+		client.Close()
+	}
+}
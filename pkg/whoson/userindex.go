@@ -0,0 +1,61 @@
+package whoson
+
+import (
+	"strings"
+	"sync"
+)
+
+// dataUser return user name of stored data, it is the first word of data.
+func dataUser(data string) string {
+	if f := strings.Fields(data); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+// userIndex hold store keys for each user, for reverse lookup.
+type userIndex struct {
+	mu    sync.RWMutex
+	users map[string]map[string]struct{}
+}
+
+func newUserIndex() *userIndex {
+	return &userIndex{
+		users: make(map[string]map[string]struct{}),
+	}
+}
+
+func (ui *userIndex) add(user, k string) {
+	if user == "" {
+		return
+	}
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	keys, ok := ui.users[user]
+	if !ok {
+		keys = make(map[string]struct{})
+		ui.users[user] = keys
+	}
+	keys[k] = struct{}{}
+}
+
+func (ui *userIndex) remove(user, k string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	if keys, ok := ui.users[user]; ok {
+		delete(keys, k)
+		if len(keys) == 0 {
+			delete(ui.users, user)
+		}
+	}
+}
+
+func (ui *userIndex) keys(user string) []string {
+	ui.mu.RLock()
+	defer ui.mu.RUnlock()
+	var keys []string
+	for k := range ui.users[user] {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,64 @@
+package whoson
+
+import (
+	"net"
+	"sort"
+	"testing"
+)
+
+func TestDataUser(t *testing.T) {
+	var tests = []struct {
+		data     string
+		expected string
+	}{
+		{"user1", "user1"},
+		{"user1 mail.example.com", "user1"},
+		{"  user1  ", "user1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if actual := dataUser(tt.data); tt.expected != actual {
+			t.Fatalf("expected %q, actual %q", tt.expected, actual)
+		}
+	}
+}
+
+func TestMemStore_FindByUser(t *testing.T) {
+	ms := NewMemStore().(MemStore)
+	set := func(ip, data string) {
+		sd := newStoreData(data)
+		sd.IP = net.ParseIP(ip)
+		ms.Set(sd.Key(), sd)
+	}
+	keys := func(user string) []string {
+		var k []string
+		for _, sd := range ms.FindByUser(user) {
+			k = append(k, sd.Key())
+		}
+		sort.Strings(k)
+		return k
+	}
+
+	set("192.0.2.1", "user1")
+	set("192.0.2.2", "user1 imap")
+	set("192.0.2.3", "user2")
+	if k := keys("user1"); len(k) != 2 || k[0] != "192.0.2.1" || k[1] != "192.0.2.2" {
+		t.Fatalf("expected 2 keys, actual %v", k)
+	}
+
+	set("192.0.2.2", "user2")
+	if k := keys("user1"); len(k) != 1 || k[0] != "192.0.2.1" {
+		t.Fatalf("expected 192.0.2.1, actual %v", k)
+	}
+	if k := keys("user2"); len(k) != 2 {
+		t.Fatalf("expected 2 keys, actual %v", k)
+	}
+
+	ms.Del("192.0.2.1")
+	if k := keys("user1"); len(k) != 0 {
+		t.Fatalf("expected no keys, actual %v", k)
+	}
+	if k := keys("nobody"); len(k) != 0 {
+		t.Fatalf("expected no keys, actual %v", k)
+	}
+}
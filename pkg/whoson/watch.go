@@ -0,0 +1,180 @@
+package whoson
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchers hold Watch streams receiving store events.
+var watchers = newWatchHub()
+
+// eventSnapshot is event of data stored before Watch started.
+const eventSnapshot = "Snapshot"
+
+// watchFilter select events by networks containing IP and user of data, empty filter selects all.
+type watchFilter struct {
+	networks []*net.IPNet
+	user     string
+}
+
+func newWatchFilter(req *WSWatchRequest) (*watchFilter, error) {
+	f := &watchFilter{user: req.Data}
+	for _, n := range req.Networks {
+		network, err := parseNetwork(n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "network %q", n)
+		}
+		f.networks = append(f.networks, network)
+	}
+	return f, nil
+}
+
+func (f *watchFilter) match(ip net.IP, data string) bool {
+	if f.user != "" && dataUser(data) != f.user {
+		return false
+	}
+	if len(f.networks) == 0 {
+		return true
+	}
+	for _, n := range f.networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// watcher hold events for a Watch stream, overflow is closed when the events are not read in time.
+type watcher struct {
+	filter   *watchFilter
+	events   chan *StoreEvent
+	overflow chan struct{}
+	once     sync.Once
+}
+
+type watchHub struct {
+	mu       sync.RWMutex
+	watchers map[*watcher]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+func (h *watchHub) subscribe(f *watchFilter) *watcher {
+	w := &watcher{
+		filter:   f,
+		events:   make(chan *StoreEvent, watchQueueSize),
+		overflow: make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.watchers[w] = struct{}{}
+	return w
+}
+
+func (h *watchHub) unsubscribe(w *watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.watchers, w)
+}
+
+// publish send ev to watchers without blocking.
+func (h *watchHub) publish(ev *StoreEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for w := range h.watchers {
+		if !w.filter.match(ev.ip, ev.Data) {
+			continue
+		}
+		select {
+		case w.events <- ev:
+		default:
+			w.once.Do(func() {
+				close(w.overflow)
+			})
+		}
+	}
+}
+
+func newWSEvent(ev *StoreEvent) *WSEvent {
+	return &WSEvent{
+		Time:   ev.Time.Unix(),
+		Event:  ev.Event,
+		Source: ev.Source,
+		Record: &WSRecord{
+			IP:     ev.IP,
+			Data:   ev.Data,
+			Expire: ev.Expire.Unix(),
+		},
+	}
+}
+
+// Watch send store events matching Networks and user of Data, after data
+// already stored as Snapshot events when Snapshot is set. The stream is
+// closed with ResourceExhausted when the client does not read events in time.
+func (s *Whoson) Watch(req *WSWatchRequest, stream grpc.ServerStreamingServer[WSEvent]) error {
+	if err := authorizeRPC(stream.Context(), mLookup); err != nil {
+		return err
+	}
+	f, err := newWatchFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	w := watchers.subscribe(f)
+	defer watchers.unsubscribe(w)
+	Log("debug", "Watch:Start", nil, nil)
+
+	if req.Snapshot {
+		if err := sendSnapshot(f, stream); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-w.overflow:
+			Log("warn", "Watch:Overflow", nil, nil)
+			return status.Error(codes.ResourceExhausted, "watch buffer overflow")
+		case ev := <-w.events:
+			if err := stream.Send(newWSEvent(ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendSnapshot send data matching f as Snapshot events, except expired data
+// which is sent as Expire event later by RunExpireChecker.
+func sendSnapshot(f *watchFilter, stream grpc.ServerStreamingServer[WSEvent]) error {
+	var sds []*StoreData
+	now := time.Now()
+	for _, sd := range MainStore.Items() {
+		if sd.Expire.After(now) && f.match(sd.IP, sd.Data) {
+			sds = append(sds, sd)
+		}
+	}
+	sort.Slice(sds, func(i, j int) bool {
+		return sds[i].Key() < sds[j].Key()
+	})
+	for _, sd := range sds {
+		ev := &WSEvent{Event: eventSnapshot, Record: newWSRecord(sd)}
+		if !sd.Created.IsZero() {
+			ev.Time = sd.Created.Unix()
+		}
+		if err := stream.Send(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package whoson
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWhoson_Watch(t *testing.T) {
+	NewLogger("discard", "error")
+	NewMainStore()
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{})
+	addr, stop := startWhosonRPC(t)
+	defer stop()
+	c, err := NewControlClient(addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer c.Close()
+
+	loginData(context.Background(), &StoreData{IP: net.ParseIP("198.18.5.1"), Data: "watchuser1"}, 0)
+	loginData(context.Background(), &StoreData{IP: net.ParseIP("198.18.6.1"), Data: "watchuser1"}, 0)
+	MainStore.SyncSet("198.18.5.3", &StoreData{IP: net.ParseIP("198.18.5.3"), Data: "watchuser1", Expire: time.Now().Add(-time.Second)})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := c.Watch(ctx, &WSWatchRequest{Networks: []string{"198.18.5.0/24"}, Data: "watchuser1", Snapshot: true})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if ev.Event != eventSnapshot || ev.Record.IP != "198.18.5.1" {
+		t.Fatalf("unexpected snapshot %v", ev)
+	}
+
+	loginData(context.Background(), &StoreData{IP: net.ParseIP("198.18.6.2"), Data: "watchuser1"}, 0)
+	loginData(context.Background(), &StoreData{IP: net.ParseIP("198.18.5.2"), Data: "watchuser2"}, 0)
+	refreshData(context.Background(), "198.18.5.1", 0)
+	logoutData(context.Background(), "198.18.5.1")
+	deleteExpireData(context.Background(), MainStore)
+
+	var expected = []struct {
+		event string
+		ip    string
+	}{
+		{hRefresh, "198.18.5.1"},
+		{hLogout, "198.18.5.1"},
+		{hExpire, "198.18.5.3"},
+	}
+	for _, tt := range expected {
+		ev, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Error %v", err)
+		}
+		if ev.Event != tt.event || ev.Source != eventLocal || ev.Record.IP != tt.ip || ev.Record.Data != "watchuser1" {
+			t.Fatalf("expected %s %s, actual %v", tt.event, tt.ip, ev)
+		}
+	}
+	for _, ip := range []string{"198.18.6.1", "198.18.6.2", "198.18.5.2"} {
+		logoutData(context.Background(), ip)
+	}
+}
+
+func TestWhoson_WatchInvalidNetwork(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	SetServerConfig(&ServerConfig{})
+	addr, stop := startWhosonRPC(t)
+	defer stop()
+	c, err := NewControlClient(addr)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	defer c.Close()
+
+	stream, err := c.Watch(context.Background(), &WSWatchRequest{Networks: []string{"198.18.5.0/33"}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, actual %v", err)
+	}
+}
+
+func TestWatchHub_Overflow(t *testing.T) {
+	h := newWatchHub()
+	w := h.subscribe(&watchFilter{})
+	other := h.subscribe(&watchFilter{user: "other"})
+	ev := newStoreEvent(hLogin, eventLocal, &StoreData{IP: net.ParseIP("198.18.7.1"), Data: "watchuser3"})
+	for i := 0; i < watchQueueSize; i++ {
+		h.publish(ev)
+	}
+	select {
+	case <-w.overflow:
+		t.Fatalf("watcher should not overflow within buffer")
+	default:
+	}
+	h.publish(ev)
+	select {
+	case <-w.overflow:
+	default:
+		t.Fatalf("watcher should overflow")
+	}
+	if len(other.events) != 0 {
+		t.Fatalf("filtered watcher should not receive events, actual %d", len(other.events))
+	}
+	h.unsubscribe(w)
+	h.publish(ev)
+	if len(h.watchers) != 1 {
+		t.Fatalf("expected 1 watcher, actual %d", len(h.watchers))
+	}
+}
@@ -0,0 +1,238 @@
+package whoson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// webhookQueue hold webhooks waiting for RunWebhookWorkers.
+	webhookQueue = make(chan *webhookJob, webhookQueueSize)
+
+	// webhookBackoff is wait before first retry, doubled for each retry.
+	webhookBackoff = time.Second
+)
+
+const (
+	webhookMaxBackoff = time.Minute
+	// webhookSignatureHeader is HMAC-SHA256 of payload with Webhook.Secret.
+	webhookSignatureHeader = "X-Gowhoson-Signature"
+	webhookEventHeader     = "X-Gowhoson-Event"
+)
+
+// Webhook hold information for an URL receiving store events.
+type Webhook struct {
+	URL string
+	// Events are "Login", "Logout", "Expire" and "Refresh".
+	Events []string
+	// Sources are "local" and "sync" of events, all events are sent when empty.
+	Sources []string
+	// Secret signs payload when set.
+	Secret string
+}
+
+// webhookList is compiled Webhook list by event.
+type webhookList map[string][]*Webhook
+
+type webhookJob struct {
+	hook    *Webhook
+	event   *StoreEvent
+	attempt int
+}
+
+func newWebhookList(hooks []Webhook) (webhookList, error) {
+	wl := make(webhookList)
+	for i := range hooks {
+		h := &hooks[i]
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.Errorf("webhook URL %q must be http or https URL", h.URL)
+		}
+		if len(h.Events) == 0 {
+			return nil, errors.Errorf("webhook %q has no events", h.URL)
+		}
+		for _, src := range h.Sources {
+			if src != eventLocal && src != eventSync {
+				return nil, errors.Errorf("webhook %q source %q not found", h.URL, src)
+			}
+		}
+		for _, e := range h.Events {
+			switch e {
+			case hLogin, hLogout, hExpire, hRefresh:
+				wl[e] = append(wl[e], h)
+			default:
+				return nil, errors.Errorf("webhook %q event %q not found", h.URL, e)
+			}
+		}
+	}
+	return wl, nil
+}
+
+// queueWebhooks queue webhooks of ev without blocking.
+func queueWebhooks(ev *StoreEvent) {
+	for _, h := range getServerConfig().webhooks[ev.Event] {
+		if h.matchSource(ev.Source) {
+			queueWebhook(&webhookJob{hook: h, event: ev})
+		}
+	}
+}
+
+// matchSource return true if Sources of h is empty or contains source.
+func (h *Webhook) matchSource(source string) bool {
+	if len(h.Sources) == 0 {
+		return true
+	}
+	for _, s := range h.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// queueWebhook queue j, j is dropped when the queue is full.
+func queueWebhook(j *webhookJob) {
+	select {
+	case webhookQueue <- j:
+	default:
+		expWebhooksDroppedTotal.Add(1)
+		Log("warn", fmt.Sprintf("queueWebhook:Dropped %s %s %s", j.hook.URL, j.event.Event, j.event.IP), nil, nil)
+	}
+}
+
+// WebhookWorkerCount return number of goroutines posting webhooks, HookWorkers when WebhookWorkers is not set.
+func (c *ServerConfig) WebhookWorkerCount() int {
+	if c.WebhookWorkers > 0 {
+		return c.WebhookWorkers
+	}
+	return c.HookWorkers
+}
+
+// WebhookTimeLimit return time limit of a webhook request.
+func (c *ServerConfig) WebhookTimeLimit() time.Duration {
+	if c.WebhookTimeout > 0 {
+		return time.Duration(c.WebhookTimeout) * time.Second
+	}
+	return WebhookTimeout
+}
+
+// webhookSign return signature of payload sent in webhookSignatureHeader.
+func webhookSign(secret string, payload []byte) string {
+	return "sha256=" + authSign(secret, string(payload))
+}
+
+// post send event to webhook URL, 2xx responses are success.
+func (j *webhookJob) post(ctx context.Context) error {
+	b, err := json.Marshal(j.event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, getServerConfig().WebhookTimeLimit())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.hook.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, j.event.Event)
+	if j.hook.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSign(j.hook.Secret, b))
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("webhook %q response %s", j.hook.URL, res.Status)
+	}
+	return nil
+}
+
+// send post j, and post it again after backoff until WebhookRetries. j is
+// not retried when stop is nil, and retry waiting for backoff is discarded
+// when stop is closed.
+func (j *webhookJob) send(ctx context.Context, stop <-chan struct{}) {
+	for {
+		err := j.post(ctx)
+		if err == nil {
+			Log("debug", fmt.Sprintf("sendWebhook:%s %s %s", j.hook.URL, j.event.Event, j.event.IP), nil, nil)
+			return
+		}
+		Log("error", "sendWebhook:Error", nil, errors.Wrapf(err, "%s %s attempt %d", j.event.Event, j.event.IP, j.attempt+1))
+		if stop == nil || j.attempt >= getServerConfig().WebhookRetries {
+			expWebhookErrorsTotal.Add(1)
+			return
+		}
+		backoff := webhookBackoff << j.attempt
+		if backoff > webhookMaxBackoff || backoff <= 0 {
+			backoff = webhookMaxBackoff
+		}
+		j.attempt++
+		t := time.NewTimer(backoff)
+		select {
+		case <-stop:
+			t.Stop()
+			expWebhooksDroppedTotal.Add(1)
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunWebhookWorkers post queued webhooks by workers goroutines, a worker
+// retries failed webhook after backoff. Queued webhooks are posted once for
+// WebhookTimeout after ctx is done, and retries waiting for backoff are discarded.
+func RunWebhookWorkers(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = HookWorkers
+	}
+	Log("info", "runWebhookWorkersStart", nil, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			webhookWorker(ctx)
+		}()
+	}
+	wg.Wait()
+	Log("info", "runWebhookWorkersStop", nil, nil)
+}
+
+func webhookWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			drainWebhooks(time.Now().Add(getServerConfig().WebhookTimeLimit()))
+			return
+		case j := <-webhookQueue:
+			j.send(context.Background(), ctx.Done())
+		}
+	}
+}
+
+// drainWebhooks post queued webhooks until the queue is empty or deadline.
+func drainWebhooks(deadline time.Time) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	for ctx.Err() == nil {
+		select {
+		case j := <-webhookQueue:
+			j.send(ctx, nil)
+		default:
+			return
+		}
+	}
+}
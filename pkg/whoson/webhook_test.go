@@ -0,0 +1,204 @@
+package whoson
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewWebhookList(t *testing.T) {
+	var tests = []struct {
+		hooks []Webhook
+		ok    bool
+	}{
+		{nil, true},
+		{[]Webhook{{URL: "https://example.com/whoson", Events: []string{"Login", "Expire"}}}, true},
+		{[]Webhook{{URL: "ftp://example.com/", Events: []string{"Login"}}}, false},
+		{[]Webhook{{URL: "/whoson", Events: []string{"Login"}}}, false},
+		{[]Webhook{{URL: "http://example.com/", Events: nil}}, false},
+		{[]Webhook{{URL: "http://example.com/", Events: []string{"Query"}}}, false},
+		{[]Webhook{{URL: "http://example.com/", Events: []string{"Login"}, Sources: []string{"local"}}}, true},
+		{[]Webhook{{URL: "http://example.com/", Events: []string{"Login"}, Sources: []string{"remote"}}}, false},
+	}
+	for i, tt := range tests {
+		_, err := newWebhookList(tt.hooks)
+		if (err == nil) != tt.ok {
+			t.Fatalf("%d: expected ok %v, actual %v", i, tt.ok, err)
+		}
+	}
+}
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	fails    int
+	requests []*http.Request
+	bodies   [][]byte
+	done     chan struct{}
+}
+
+func (wr *webhookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.requests = append(wr.requests, r)
+	wr.bodies = append(wr.bodies, b)
+	if wr.fails > 0 {
+		wr.fails--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	wr.done <- struct{}{}
+}
+
+func TestRunWebhookWorkers(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	defer func(d time.Duration) { webhookBackoff = d }(webhookBackoff)
+	webhookBackoff = 10 * time.Millisecond
+
+	wr := &webhookRecorder{fails: 2, done: make(chan struct{}, 1)}
+	ts := httptest.NewServer(wr)
+	defer ts.Close()
+	err := SetServerConfig(&ServerConfig{
+		Webhooks: []Webhook{
+			{URL: ts.URL + "/login", Events: []string{hLogin}, Secret: "secret"},
+		},
+		WebhookRetries: 2,
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunWebhookWorkers(ctx, 1)
+
+	sd := &StoreData{IP: net.ParseIP("192.0.2.1"), Data: "user1", Expire: time.Now()}
+	storeEvent(hLogout, eventLocal, sd)
+	storeEvent(hLogin, eventSync, sd)
+	select {
+	case <-wr.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("webhook was not delivered")
+	}
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if len(wr.requests) != 3 {
+		t.Fatalf("expected 3 attempts, actual %d", len(wr.requests))
+	}
+	r, b := wr.requests[2], wr.bodies[2]
+	if r.URL.Path != "/login" || r.Header.Get(webhookEventHeader) != hLogin {
+		t.Fatalf("unexpected request %v %v", r.URL, r.Header)
+	}
+	if sig := r.Header.Get(webhookSignatureHeader); sig != webhookSign("secret", b) {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+	ev := &StoreEvent{}
+	if err := json.Unmarshal(b, ev); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	if ev.Event != hLogin || ev.Source != eventSync || ev.IP != "192.0.2.1" || ev.Data != "user1" {
+		t.Fatalf("unexpected payload %s", b)
+	}
+}
+
+func TestWebhookJob_Retries(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	defer func(d time.Duration) { webhookBackoff = d }(webhookBackoff)
+	webhookBackoff = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	attempts := 0
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	SetServerConfig(&ServerConfig{WebhookRetries: 1})
+
+	failed := expWebhookErrorsTotal.Value()
+	j := &webhookJob{hook: &Webhook{URL: ts.URL}, event: &StoreEvent{Event: hLogin}}
+	j.send(context.Background(), make(chan struct{}))
+	if n := count(); n != 2 || j.attempt != 1 {
+		t.Fatalf("expected 2 attempts, actual %d %d", n, j.attempt)
+	}
+	if expWebhookErrorsTotal.Value() != failed+1 {
+		t.Fatalf("webhook should give up after retries")
+	}
+
+	// retry waiting for backoff is discarded on stop.
+	webhookBackoff = time.Hour
+	dropped := expWebhooksDroppedTotal.Value()
+	stop := make(chan struct{})
+	close(stop)
+	j = &webhookJob{hook: &Webhook{URL: ts.URL}, event: &StoreEvent{Event: hLogin}}
+	j.send(context.Background(), stop)
+	if n := count(); n != 3 || expWebhooksDroppedTotal.Value() != dropped+1 {
+		t.Fatalf("expected retry discarded, actual attempts %d", n)
+	}
+	select {
+	case <-webhookQueue:
+		t.Fatalf("webhook should not be queued again")
+	default:
+	}
+}
+
+func TestQueueWebhooks_Sources(t *testing.T) {
+	NewLogger("discard", "error")
+	defer SetServerConfig(nil)
+	err := SetServerConfig(&ServerConfig{
+		Webhooks: []Webhook{
+			{URL: "http://198.18.11.1/local", Events: []string{hLogin}, Sources: []string{eventLocal}},
+			{URL: "http://198.18.11.1/all", Events: []string{hLogin}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+	sd := &StoreData{IP: net.ParseIP("192.0.2.1"), Data: "user1", Expire: time.Now()}
+	queueWebhooks(newStoreEvent(hLogin, eventSync, sd))
+	queueWebhooks(newStoreEvent(hLogin, eventLocal, sd))
+
+	var expected = []struct {
+		url    string
+		source string
+	}{
+		{"http://198.18.11.1/all", eventSync},
+		{"http://198.18.11.1/local", eventLocal},
+		{"http://198.18.11.1/all", eventLocal},
+	}
+	for _, tt := range expected {
+		j := <-webhookQueue
+		if j.hook.URL != tt.url || j.event.Source != tt.source {
+			t.Fatalf("expected %s %s, actual %s %s", tt.url, tt.source, j.hook.URL, j.event.Source)
+		}
+	}
+	if n := len(webhookQueue); n != 0 {
+		t.Fatalf("expected empty queue, actual %d", n)
+	}
+}
+
+func TestServerConfig_WebhookWorkerCount(t *testing.T) {
+	if n := (&ServerConfig{HookWorkers: 2}).WebhookWorkerCount(); n != 2 {
+		t.Fatalf("expected HookWorkers 2, actual %d", n)
+	}
+	if n := (&ServerConfig{HookWorkers: 2, WebhookWorkers: 8}).WebhookWorkerCount(); n != 8 {
+		t.Fatalf("expected WebhookWorkers 8, actual %d", n)
+	}
+}
@@ -0,0 +1,654 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.32.1
+// source: pkg/whoson/whoson.proto
+
+package whoson
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WSRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IP            string                 `protobuf:"bytes,1,opt,name=IP,proto3" json:"IP,omitempty"`
+	Data          string                 `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	Expire        int64                  `protobuf:"varint,3,opt,name=Expire,proto3" json:"Expire,omitempty"`
+	TTL           int64                  `protobuf:"varint,4,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	Created       int64                  `protobuf:"varint,5,opt,name=Created,proto3" json:"Created,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSRecord) Reset() {
+	*x = WSRecord{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSRecord) ProtoMessage() {}
+
+func (x *WSRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSRecord.ProtoReflect.Descriptor instead.
+func (*WSRecord) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WSRecord) GetIP() string {
+	if x != nil {
+		return x.IP
+	}
+	return ""
+}
+
+func (x *WSRecord) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *WSRecord) GetExpire() int64 {
+	if x != nil {
+		return x.Expire
+	}
+	return 0
+}
+
+func (x *WSRecord) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+func (x *WSRecord) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+type WSLoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IP            string                 `protobuf:"bytes,1,opt,name=IP,proto3" json:"IP,omitempty"`
+	Data          string                 `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	TTL           int64                  `protobuf:"varint,3,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSLoginRequest) Reset() {
+	*x = WSLoginRequest{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSLoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSLoginRequest) ProtoMessage() {}
+
+func (x *WSLoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSLoginRequest.ProtoReflect.Descriptor instead.
+func (*WSLoginRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WSLoginRequest) GetIP() string {
+	if x != nil {
+		return x.IP
+	}
+	return ""
+}
+
+func (x *WSLoginRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *WSLoginRequest) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+type WSLogoutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IP            string                 `protobuf:"bytes,1,opt,name=IP,proto3" json:"IP,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSLogoutRequest) Reset() {
+	*x = WSLogoutRequest{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSLogoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSLogoutRequest) ProtoMessage() {}
+
+func (x *WSLogoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSLogoutRequest.ProtoReflect.Descriptor instead.
+func (*WSLogoutRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WSLogoutRequest) GetIP() string {
+	if x != nil {
+		return x.IP
+	}
+	return ""
+}
+
+type WSQueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IP            string                 `protobuf:"bytes,1,opt,name=IP,proto3" json:"IP,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSQueryRequest) Reset() {
+	*x = WSQueryRequest{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSQueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSQueryRequest) ProtoMessage() {}
+
+func (x *WSQueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSQueryRequest.ProtoReflect.Descriptor instead.
+func (*WSQueryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WSQueryRequest) GetIP() string {
+	if x != nil {
+		return x.IP
+	}
+	return ""
+}
+
+type WSRefreshRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IP            string                 `protobuf:"bytes,1,opt,name=IP,proto3" json:"IP,omitempty"`
+	TTL           int64                  `protobuf:"varint,2,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSRefreshRequest) Reset() {
+	*x = WSRefreshRequest{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSRefreshRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSRefreshRequest) ProtoMessage() {}
+
+func (x *WSRefreshRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSRefreshRequest.ProtoReflect.Descriptor instead.
+func (*WSRefreshRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WSRefreshRequest) GetIP() string {
+	if x != nil {
+		return x.IP
+	}
+	return ""
+}
+
+func (x *WSRefreshRequest) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+type WSListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          string                 `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=Limit,proto3" json:"Limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSListRequest) Reset() {
+	*x = WSListRequest{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSListRequest) ProtoMessage() {}
+
+func (x *WSListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSListRequest.ProtoReflect.Descriptor instead.
+func (*WSListRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WSListRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *WSListRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *WSListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type WSListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int32                  `protobuf:"varint,1,opt,name=Total,proto3" json:"Total,omitempty"`
+	Records       []*WSRecord            `protobuf:"bytes,2,rep,name=Records,proto3" json:"Records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSListResponse) Reset() {
+	*x = WSListResponse{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSListResponse) ProtoMessage() {}
+
+func (x *WSListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSListResponse.ProtoReflect.Descriptor instead.
+func (*WSListResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WSListResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *WSListResponse) GetRecords() []*WSRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type WSWatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Networks      []string               `protobuf:"bytes,1,rep,name=Networks,proto3" json:"Networks,omitempty"`
+	Data          string                 `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	Snapshot      bool                   `protobuf:"varint,3,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSWatchRequest) Reset() {
+	*x = WSWatchRequest{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSWatchRequest) ProtoMessage() {}
+
+func (x *WSWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSWatchRequest.ProtoReflect.Descriptor instead.
+func (*WSWatchRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WSWatchRequest) GetNetworks() []string {
+	if x != nil {
+		return x.Networks
+	}
+	return nil
+}
+
+func (x *WSWatchRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *WSWatchRequest) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+type WSEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          int64                  `protobuf:"varint,1,opt,name=Time,proto3" json:"Time,omitempty"`
+	Event         string                 `protobuf:"bytes,2,opt,name=Event,proto3" json:"Event,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=Source,proto3" json:"Source,omitempty"`
+	Record        *WSRecord              `protobuf:"bytes,4,opt,name=Record,proto3" json:"Record,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WSEvent) Reset() {
+	*x = WSEvent{}
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WSEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WSEvent) ProtoMessage() {}
+
+func (x *WSEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_whoson_whoson_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WSEvent.ProtoReflect.Descriptor instead.
+func (*WSEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_whoson_whoson_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WSEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *WSEvent) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *WSEvent) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *WSEvent) GetRecord() *WSRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+var File_pkg_whoson_whoson_proto protoreflect.FileDescriptor
+
+const file_pkg_whoson_whoson_proto_rawDesc = "" +
+	"\n" +
+	"\x17pkg/whoson/whoson.proto\x12\x06whoson\"r\n" +
+	"\bWSRecord\x12\x0e\n" +
+	"\x02IP\x18\x01 \x01(\tR\x02IP\x12\x12\n" +
+	"\x04Data\x18\x02 \x01(\tR\x04Data\x12\x16\n" +
+	"\x06Expire\x18\x03 \x01(\x03R\x06Expire\x12\x10\n" +
+	"\x03TTL\x18\x04 \x01(\x03R\x03TTL\x12\x18\n" +
+	"\aCreated\x18\x05 \x01(\x03R\aCreated\"F\n" +
+	"\x0eWSLoginRequest\x12\x0e\n" +
+	"\x02IP\x18\x01 \x01(\tR\x02IP\x12\x12\n" +
+	"\x04Data\x18\x02 \x01(\tR\x04Data\x12\x10\n" +
+	"\x03TTL\x18\x03 \x01(\x03R\x03TTL\"!\n" +
+	"\x0fWSLogoutRequest\x12\x0e\n" +
+	"\x02IP\x18\x01 \x01(\tR\x02IP\" \n" +
+	"\x0eWSQueryRequest\x12\x0e\n" +
+	"\x02IP\x18\x01 \x01(\tR\x02IP\"4\n" +
+	"\x10WSRefreshRequest\x12\x0e\n" +
+	"\x02IP\x18\x01 \x01(\tR\x02IP\x12\x10\n" +
+	"\x03TTL\x18\x02 \x01(\x03R\x03TTL\"Q\n" +
+	"\rWSListRequest\x12\x12\n" +
+	"\x04Data\x18\x01 \x01(\tR\x04Data\x12\x16\n" +
+	"\x06Offset\x18\x02 \x01(\x05R\x06Offset\x12\x14\n" +
+	"\x05Limit\x18\x03 \x01(\x05R\x05Limit\"R\n" +
+	"\x0eWSListResponse\x12\x14\n" +
+	"\x05Total\x18\x01 \x01(\x05R\x05Total\x12*\n" +
+	"\aRecords\x18\x02 \x03(\v2\x10.whoson.WSRecordR\aRecords\"\\\n" +
+	"\x0eWSWatchRequest\x12\x1a\n" +
+	"\bNetworks\x18\x01 \x03(\tR\bNetworks\x12\x12\n" +
+	"\x04Data\x18\x02 \x01(\tR\x04Data\x12\x1a\n" +
+	"\bSnapshot\x18\x03 \x01(\bR\bSnapshot\"u\n" +
+	"\aWSEvent\x12\x12\n" +
+	"\x04Time\x18\x01 \x01(\x03R\x04Time\x12\x14\n" +
+	"\x05Event\x18\x02 \x01(\tR\x05Event\x12\x16\n" +
+	"\x06Source\x18\x03 \x01(\tR\x06Source\x12(\n" +
+	"\x06Record\x18\x04 \x01(\v2\x10.whoson.WSRecordR\x06Record2\xd1\x02\n" +
+	"\x06whoson\x123\n" +
+	"\x05Login\x12\x16.whoson.WSLoginRequest\x1a\x10.whoson.WSRecord\"\x00\x125\n" +
+	"\x06Logout\x12\x17.whoson.WSLogoutRequest\x1a\x10.whoson.WSRecord\"\x00\x123\n" +
+	"\x05Query\x12\x16.whoson.WSQueryRequest\x1a\x10.whoson.WSRecord\"\x00\x127\n" +
+	"\aRefresh\x12\x18.whoson.WSRefreshRequest\x1a\x10.whoson.WSRecord\"\x00\x127\n" +
+	"\x04List\x12\x15.whoson.WSListRequest\x1a\x16.whoson.WSListResponse\"\x00\x124\n" +
+	"\x05Watch\x12\x16.whoson.WSWatchRequest\x1a\x0f.whoson.WSEvent\"\x000\x01B-Z+github.com/tai-ga/gowhoso/pkg/whoson;whosonb\x06proto3"
+
+var (
+	file_pkg_whoson_whoson_proto_rawDescOnce sync.Once
+	file_pkg_whoson_whoson_proto_rawDescData []byte
+)
+
+func file_pkg_whoson_whoson_proto_rawDescGZIP() []byte {
+	file_pkg_whoson_whoson_proto_rawDescOnce.Do(func() {
+		file_pkg_whoson_whoson_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_whoson_whoson_proto_rawDesc), len(file_pkg_whoson_whoson_proto_rawDesc)))
+	})
+	return file_pkg_whoson_whoson_proto_rawDescData
+}
+
+var file_pkg_whoson_whoson_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pkg_whoson_whoson_proto_goTypes = []any{
+	(*WSRecord)(nil),         // 0: whoson.WSRecord
+	(*WSLoginRequest)(nil),   // 1: whoson.WSLoginRequest
+	(*WSLogoutRequest)(nil),  // 2: whoson.WSLogoutRequest
+	(*WSQueryRequest)(nil),   // 3: whoson.WSQueryRequest
+	(*WSRefreshRequest)(nil), // 4: whoson.WSRefreshRequest
+	(*WSListRequest)(nil),    // 5: whoson.WSListRequest
+	(*WSListResponse)(nil),   // 6: whoson.WSListResponse
+	(*WSWatchRequest)(nil),   // 7: whoson.WSWatchRequest
+	(*WSEvent)(nil),          // 8: whoson.WSEvent
+}
+var file_pkg_whoson_whoson_proto_depIdxs = []int32{
+	0, // 0: whoson.WSListResponse.Records:type_name -> whoson.WSRecord
+	0, // 1: whoson.WSEvent.Record:type_name -> whoson.WSRecord
+	1, // 2: whoson.whoson.Login:input_type -> whoson.WSLoginRequest
+	2, // 3: whoson.whoson.Logout:input_type -> whoson.WSLogoutRequest
+	3, // 4: whoson.whoson.Query:input_type -> whoson.WSQueryRequest
+	4, // 5: whoson.whoson.Refresh:input_type -> whoson.WSRefreshRequest
+	5, // 6: whoson.whoson.List:input_type -> whoson.WSListRequest
+	7, // 7: whoson.whoson.Watch:input_type -> whoson.WSWatchRequest
+	0, // 8: whoson.whoson.Login:output_type -> whoson.WSRecord
+	0, // 9: whoson.whoson.Logout:output_type -> whoson.WSRecord
+	0, // 10: whoson.whoson.Query:output_type -> whoson.WSRecord
+	0, // 11: whoson.whoson.Refresh:output_type -> whoson.WSRecord
+	6, // 12: whoson.whoson.List:output_type -> whoson.WSListResponse
+	8, // 13: whoson.whoson.Watch:output_type -> whoson.WSEvent
+	8, // [8:14] is the sub-list for method output_type
+	2, // [2:8] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_whoson_whoson_proto_init() }
+func file_pkg_whoson_whoson_proto_init() {
+	if File_pkg_whoson_whoson_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_whoson_whoson_proto_rawDesc), len(file_pkg_whoson_whoson_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_whoson_whoson_proto_goTypes,
+		DependencyIndexes: file_pkg_whoson_whoson_proto_depIdxs,
+		MessageInfos:      file_pkg_whoson_whoson_proto_msgTypes,
+	}.Build()
+	File_pkg_whoson_whoson_proto = out.File
+	file_pkg_whoson_whoson_proto_goTypes = nil
+	file_pkg_whoson_whoson_proto_depIdxs = nil
+}
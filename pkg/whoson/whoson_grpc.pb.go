@@ -0,0 +1,315 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: pkg/whoson/whoson.proto
+
+package whoson
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Whoson_Login_FullMethodName   = "/whoson.whoson/Login"
+	Whoson_Logout_FullMethodName  = "/whoson.whoson/Logout"
+	Whoson_Query_FullMethodName   = "/whoson.whoson/Query"
+	Whoson_Refresh_FullMethodName = "/whoson.whoson/Refresh"
+	Whoson_List_FullMethodName    = "/whoson.whoson/List"
+	Whoson_Watch_FullMethodName   = "/whoson.whoson/Watch"
+)
+
+// WhosonClient is the client API for Whoson service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WhosonClient interface {
+	Login(ctx context.Context, in *WSLoginRequest, opts ...grpc.CallOption) (*WSRecord, error)
+	Logout(ctx context.Context, in *WSLogoutRequest, opts ...grpc.CallOption) (*WSRecord, error)
+	Query(ctx context.Context, in *WSQueryRequest, opts ...grpc.CallOption) (*WSRecord, error)
+	Refresh(ctx context.Context, in *WSRefreshRequest, opts ...grpc.CallOption) (*WSRecord, error)
+	List(ctx context.Context, in *WSListRequest, opts ...grpc.CallOption) (*WSListResponse, error)
+	Watch(ctx context.Context, in *WSWatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WSEvent], error)
+}
+
+type whosonClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWhosonClient(cc grpc.ClientConnInterface) WhosonClient {
+	return &whosonClient{cc}
+}
+
+func (c *whosonClient) Login(ctx context.Context, in *WSLoginRequest, opts ...grpc.CallOption) (*WSRecord, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSRecord)
+	err := c.cc.Invoke(ctx, Whoson_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *whosonClient) Logout(ctx context.Context, in *WSLogoutRequest, opts ...grpc.CallOption) (*WSRecord, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSRecord)
+	err := c.cc.Invoke(ctx, Whoson_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *whosonClient) Query(ctx context.Context, in *WSQueryRequest, opts ...grpc.CallOption) (*WSRecord, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSRecord)
+	err := c.cc.Invoke(ctx, Whoson_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *whosonClient) Refresh(ctx context.Context, in *WSRefreshRequest, opts ...grpc.CallOption) (*WSRecord, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSRecord)
+	err := c.cc.Invoke(ctx, Whoson_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *whosonClient) List(ctx context.Context, in *WSListRequest, opts ...grpc.CallOption) (*WSListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WSListResponse)
+	err := c.cc.Invoke(ctx, Whoson_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *whosonClient) Watch(ctx context.Context, in *WSWatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WSEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Whoson_ServiceDesc.Streams[0], Whoson_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WSWatchRequest, WSEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Whoson_WatchClient = grpc.ServerStreamingClient[WSEvent]
+
+// WhosonServer is the server API for Whoson service.
+// All implementations must embed UnimplementedWhosonServer
+// for forward compatibility.
+type WhosonServer interface {
+	Login(context.Context, *WSLoginRequest) (*WSRecord, error)
+	Logout(context.Context, *WSLogoutRequest) (*WSRecord, error)
+	Query(context.Context, *WSQueryRequest) (*WSRecord, error)
+	Refresh(context.Context, *WSRefreshRequest) (*WSRecord, error)
+	List(context.Context, *WSListRequest) (*WSListResponse, error)
+	Watch(*WSWatchRequest, grpc.ServerStreamingServer[WSEvent]) error
+	mustEmbedUnimplementedWhosonServer()
+}
+
+// UnimplementedWhosonServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWhosonServer struct{}
+
+func (UnimplementedWhosonServer) Login(context.Context, *WSLoginRequest) (*WSRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedWhosonServer) Logout(context.Context, *WSLogoutRequest) (*WSRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedWhosonServer) Query(context.Context, *WSQueryRequest) (*WSRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedWhosonServer) Refresh(context.Context, *WSRefreshRequest) (*WSRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedWhosonServer) List(context.Context, *WSListRequest) (*WSListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedWhosonServer) Watch(*WSWatchRequest, grpc.ServerStreamingServer[WSEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedWhosonServer) mustEmbedUnimplementedWhosonServer() {}
+func (UnimplementedWhosonServer) testEmbeddedByValue()                {}
+
+// UnsafeWhosonServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WhosonServer will
+// result in compilation errors.
+type UnsafeWhosonServer interface {
+	mustEmbedUnimplementedWhosonServer()
+}
+
+func RegisterWhosonServer(s grpc.ServiceRegistrar, srv WhosonServer) {
+	// If the following call pancis, it indicates UnimplementedWhosonServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Whoson_ServiceDesc, srv)
+}
+
+func _Whoson_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WhosonServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Whoson_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WhosonServer).Login(ctx, req.(*WSLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Whoson_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSLogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WhosonServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Whoson_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WhosonServer).Logout(ctx, req.(*WSLogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Whoson_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WhosonServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Whoson_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WhosonServer).Query(ctx, req.(*WSQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Whoson_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSRefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WhosonServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Whoson_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WhosonServer).Refresh(ctx, req.(*WSRefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Whoson_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WSListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WhosonServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Whoson_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WhosonServer).List(ctx, req.(*WSListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Whoson_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WSWatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WhosonServer).Watch(m, &grpc.GenericServerStream[WSWatchRequest, WSEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Whoson_WatchServer = grpc.ServerStreamingServer[WSEvent]
+
+// Whoson_ServiceDesc is the grpc.ServiceDesc for Whoson service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Whoson_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "whoson.whoson",
+	HandlerType: (*WhosonServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _Whoson_Login_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _Whoson_Logout_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Whoson_Query_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _Whoson_Refresh_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Whoson_List_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Whoson_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/whoson/whoson.proto",
+}
@@ -9,12 +9,16 @@ Summary: gowhoson
 License: MIT
 Group: Networking/Daemons
 Source0: %{name}
-Source1: %{name}.init
+Source1: %{name}.service
 Source2: %{name}.json
+Source3: %{name}.socket
+Source4: %{name}-control.socket
 URL: https://github.com/tai-ga/gowhoson
 BuildRoot: /var/tmp/%{name}-build
-BuildRequires: initscripts
-Requires: initscripts
+BuildRequires: systemd
+Requires(post): systemd
+Requires(preun): systemd
+Requires(postun): systemd
 
 %description
 gowhoson is a golang implementation of the "Whoson" protocol.
@@ -28,21 +32,17 @@ rm -rf $RPM_BUILD_ROOT
 mkdir -p $RPM_BUILD_ROOT/usr/sbin
 mkdir -p $RPM_BUILD_ROOT/etc/sysconfig
 mkdir -p $RPM_BUILD_ROOT/etc/logrotate.d
-mkdir -p $RPM_BUILD_ROOT/etc/rc.d/{init.d,rc0.d,rc1.d,rc2.d,rc3.d,rc4.d,rc5.d,rc6.d}
+mkdir -p $RPM_BUILD_ROOT%{_unitdir}
+mkdir -p $RPM_BUILD_ROOT/var/log/%{name}
+mkdir -p $RPM_BUILD_ROOT/var/lib/%{name}
 install -m755 $RPM_SOURCE_DIR/%{name} $RPM_BUILD_ROOT/usr/sbin
-install -m755 $RPM_SOURCE_DIR/%{name}.init $RPM_BUILD_ROOT/etc/rc.d/init.d/%{name}
+install -m644 $RPM_SOURCE_DIR/%{name}.service $RPM_BUILD_ROOT%{_unitdir}/%{name}.service
+install -m644 $RPM_SOURCE_DIR/%{name}.socket $RPM_BUILD_ROOT%{_unitdir}/%{name}.socket
+install -m644 $RPM_SOURCE_DIR/%{name}-control.socket $RPM_BUILD_ROOT%{_unitdir}/%{name}-control.socket
 install -m644 $RPM_SOURCE_DIR/%{name}.json $RPM_BUILD_ROOT/etc/%{name}.json
 install -m644 $RPM_SOURCE_DIR/%{name}.sysconfig $RPM_BUILD_ROOT/etc/sysconfig/%{name}
 install -m644 $RPM_SOURCE_DIR/%{name}.logrotate $RPM_BUILD_ROOT/etc/logrotate.d/%{name}
 
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc0.d/K30%{name}
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc1.d/K30%{name}
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc2.d/S80%{name}
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc3.d/S80%{name}
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc4.d/S80%{name}
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc5.d/S80%{name}
-ln -sf ../init.d/%{name} $RPM_BUILD_ROOT/etc/rc.d/rc6.d/K30%{name}
-
 %pre
 if ! grep -q "^gowhoson:" /etc/group; then
     groupadd gowhoson
@@ -52,11 +52,13 @@ if ! grep -q "^gowhoson:" /etc/passwd; then
 fi
 
 %post
+%systemd_post %{name}.service %{name}.socket %{name}-control.socket
 
 %preun
-if [ "$1" = 0 ]; then
-  /sbin/chkconfig --del %{name}
-fi
+%systemd_preun %{name}.service %{name}.socket %{name}-control.socket
+
+%postun
+%systemd_postun_with_restart %{name}.service
 
 %files
 %defattr(-,root,root)
@@ -67,14 +69,12 @@ fi
 %config /etc/%{name}.json
 %config /etc/sysconfig/%{name}
 %config /etc/logrotate.d/%{name}
-%config /etc/rc.d/init.d/%{name}
-%config(missingok) /etc/rc.d/rc0.d/K30%{name}
-%config(missingok) /etc/rc.d/rc1.d/K30%{name}
-%config(missingok) /etc/rc.d/rc2.d/S80%{name}
-%config(missingok) /etc/rc.d/rc3.d/S80%{name}
-%config(missingok) /etc/rc.d/rc4.d/S80%{name}
-%config(missingok) /etc/rc.d/rc5.d/S80%{name}
-%config(missingok) /etc/rc.d/rc6.d/K30%{name}
+%{_unitdir}/%{name}.service
+%{_unitdir}/%{name}.socket
+%{_unitdir}/%{name}-control.socket
+
+%dir %attr(755,gowhoson,gowhoson) /var/log/%{name}
+%dir %attr(755,gowhoson,gowhoson) /var/lib/%{name}
 
 %changelog
 * Thu Nov 19 2020 Masahiro Ono <masahiro.o@gmail.com> gowhoson-v0.2.4-1